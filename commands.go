@@ -0,0 +1,141 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/SaharKhamseh/cinema-backend/controller"
+	"github.com/SaharKhamseh/cinema-backend/database"
+)
+
+// runCommand dispatches a maintenance command and returns the exit code
+func runCommand(name string, args []string) int {
+	switch name {
+	case "import-movies":
+		return importMoviesCommand(args)
+	case "recommend":
+		return recommendCommand(args)
+	case "repair-capacity":
+		return repairCapacityCommand(args)
+	}
+
+	fmt.Fprintf(os.Stderr, "unknown command %q\n", name)
+	fmt.Fprintln(os.Stderr, "commands: import-movies, recommend, repair-capacity")
+	return 2
+}
+
+// importMoviesCommand imports a CSV or JSON movie file and prints the
+// per-row report as JSON
+//
+//	cinema-backend import-movies [-dry-run] [-chain ID] [-format csv|json] movies.csv
+func importMoviesCommand(args []string) int {
+	flags := flag.NewFlagSet("import-movies", flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "validate the file without saving anything")
+	format := flags.String("format", "", "csv or json, defaults to the file extension")
+	chainID := flags.Uint("chain", database.DefaultChainID, "id of the chain the movies belong to")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: import-movies [-dry-run] [-chain ID] [-format csv|json] FILE")
+		return 2
+	}
+
+	path := flags.Arg(0)
+	if *format == "" {
+		*format = strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer file.Close()
+
+	rows, err := controller.ParseMovieFile(file, *format)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	report, err := controller.ImportMovies(rows, *dryRun, *chainID, 0)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "import failed:", err)
+		return 1
+	}
+
+	out := json.NewEncoder(os.Stdout)
+	out.SetIndent("", "  ")
+	out.Encode(report)
+
+	if report.Failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// recommendCommand recomputes the cached recommendations, for every user or
+// only the given ones. Run it periodically, e.g. nightly from cron.
+//
+//	cinema-backend recommend [USER_ID...]
+func recommendCommand(args []string) int {
+	var userIDs []uint
+	for _, arg := range args {
+		id, err := strconv.ParseUint(arg, 10, 32)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "usage: recommend [USER_ID...]")
+			return 2
+		}
+		userIDs = append(userIDs, uint(id))
+	}
+
+	count, err := controller.RefreshRecommendations(userIDs...)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "recommendations failed:", err)
+		return 1
+	}
+
+	fmt.Printf("recommendations refreshed for %d users\n", count)
+	return 0
+}
+
+// repairCapacityCommand rewrites screen capacities and layouts that no
+// longer match their seat rows and prints what was repaired and what is
+// left, as JSON. Theaters with more seats than their licensed capacity are
+// only raised with -raise-licensed.
+//
+//	cinema-backend repair-capacity [-dry-run] [-chain ID] [-raise-licensed]
+func repairCapacityCommand(args []string) int {
+	flags := flag.NewFlagSet("repair-capacity", flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "report what would be repaired without saving anything")
+	chainID := flags.Uint("chain", 0, "only repair the theaters of this chain, 0 for every chain")
+	raiseLicensed := flags.Bool("raise-licensed", false, "raise licensed capacities to the installed seats")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 0 {
+		fmt.Fprintln(os.Stderr, "usage: repair-capacity [-dry-run] [-chain ID] [-raise-licensed]")
+		return 2
+	}
+
+	repair, err := controller.RepairCapacity(*chainID, *raiseLicensed, *dryRun)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "repair failed:", err)
+		return 1
+	}
+
+	out := json.NewEncoder(os.Stdout)
+	out.SetIndent("", "  ")
+	out.Encode(repair)
+
+	if len(repair.Remaining) > 0 {
+		return 1
+	}
+	return 0
+}
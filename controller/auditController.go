@@ -0,0 +1,145 @@
+package controller
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+
+	"github.com/SaharKhamseh/cinema-backend/database"
+	"github.com/SaharKhamseh/cinema-backend/models"
+	"github.com/SaharKhamseh/cinema-backend/util"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// currentUserID returns the id of the user the jwt cookie was issued to
+func currentUserID(c *fiber.Ctx) (uint, error) {
+	userIdStr, err := util.Parsejwt(c.Cookies("jwt"))
+	if err != nil {
+		return 0, err
+	}
+
+	userId, err := strconv.ParseUint(userIdStr, 10, 32)
+	if err != nil {
+		return 0, err
+	}
+
+	return uint(userId), nil
+}
+
+// snapshot turns an entity into a flat JSON object so it can be stored and diffed
+func snapshot(entity interface{}) map[string]interface{} {
+	if entity == nil {
+		return nil
+	}
+
+	raw, err := json.Marshal(entity)
+	if err != nil {
+		return nil
+	}
+
+	var fields map[string]interface{}
+	json.Unmarshal(raw, &fields)
+	return fields
+}
+
+// diffSnapshots lists every top-level field whose value changed
+func diffSnapshots(before, after map[string]interface{}) map[string]fiber.Map {
+	diff := map[string]fiber.Map{}
+
+	for key, oldValue := range before {
+		newValue, ok := after[key]
+		if !ok || !reflect.DeepEqual(oldValue, newValue) {
+			diff[key] = fiber.Map{"from": oldValue, "to": newValue}
+		}
+	}
+	for key, newValue := range after {
+		if _, ok := before[key]; !ok {
+			diff[key] = fiber.Map{"from": nil, "to": newValue}
+		}
+	}
+
+	return diff
+}
+
+// recordAudit writes an audit entry using the caller's transaction so the
+// entry is only kept when the change itself is committed. Pass nil for
+// before on creates and for after on deletes.
+func recordAudit(tx *gorm.DB, c *fiber.Ctx, action, entityType string, entityID uint, before, after interface{}) error {
+	actorID, _ := currentUserID(c)
+	return writeAudit(tx, chainID(c), actorID, action, entityType, entityID, before, after)
+}
+
+// writeAudit is recordAudit for callers without a request, such as CLI
+// commands, which pass actor 0
+func writeAudit(tx *gorm.DB, chainID, actorID uint, action, entityType string, entityID uint, before, after interface{}) error {
+	beforeFields := snapshot(before)
+	afterFields := snapshot(after)
+
+	entry := models.AuditLog{
+		ChainID:    chainID,
+		ActorID:    actorID,
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+	}
+
+	if beforeFields != nil {
+		raw, _ := json.Marshal(beforeFields)
+		entry.Before = string(raw)
+	}
+	if afterFields != nil {
+		raw, _ := json.Marshal(afterFields)
+		entry.After = string(raw)
+	}
+	raw, _ := json.Marshal(diffSnapshots(beforeFields, afterFields))
+	entry.Diff = string(raw)
+
+	return tx.Create(&entry).Error
+}
+
+// GetAuditLogs returns audit entries, newest first, with optional filters
+func GetAuditLogs(c *fiber.Ctx) error {
+	query := database.DB.Scopes(inChain(c)).Order("created_at DESC, id DESC")
+
+	if v := c.Query("entity_type"); v != "" {
+		query = query.Where("entity_type = ?", v)
+	}
+	if v := c.Query("entity_id"); v != "" {
+		query = query.Where("entity_id = ?", v)
+	}
+	if v := c.Query("actor_id"); v != "" {
+		query = query.Where("actor_id = ?", v)
+	}
+	if v := c.Query("action"); v != "" {
+		query = query.Where("action = ?", v)
+	}
+
+	from, to, err := parseDateRange(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+	if !from.IsZero() {
+		query = query.Where("created_at >= ?", from)
+	}
+	if !to.IsZero() {
+		query = query.Where("created_at < ?", to)
+	}
+
+	limit := c.QueryInt("limit", 100)
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+
+	var logs []models.AuditLog
+	if err := query.Limit(limit).Find(&logs).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to fetch audit log",
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(logs)
+}
@@ -62,9 +62,9 @@ func Register(c *fiber.Ctx) error {
 		})
 	}
 
-	// Check if email already exists
+	// Check if email already exists, accounts are per chain
 	var existingUser models.User
-	if err := database.DB.Where("email = ?", email).First(&existingUser).Error; err == nil {
+	if err := database.DB.Scopes(inChain(c)).Where("email = ?", email).First(&existingUser).Error; err == nil {
 		return c.Status(400).JSON(fiber.Map{
 			"message": "Email already exists",
 		})
@@ -72,6 +72,7 @@ func Register(c *fiber.Ctx) error {
 
 	// Create new user with trimmed values
 	user := models.User{
+		ChainID:   chainID(c),
 		FirstName: strings.TrimSpace(data["first_name"].(string)),
 		LastName:  strings.TrimSpace(data["last_name"].(string)),
 		Phone:     strings.TrimSpace(data["phone"].(string)),
@@ -101,7 +102,7 @@ func Login(c *fiber.Ctx) error {
 	}
 
 	var user models.User
-	database.DB.Where("email=?", data["email"]).First(&user)
+	database.DB.Scopes(inChain(c)).Where("email=?", data["email"]).First(&user)
 	if user.Id == 0 {
 		c.Status(404)
 		return c.JSON(fiber.Map{
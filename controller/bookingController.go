@@ -1,13 +1,16 @@
 package controller
 
 import (
+	"fmt"
 	"strconv"
 	"time"
 
 	"github.com/SaharKhamseh/cinema-backend/database"
+	"github.com/SaharKhamseh/cinema-backend/middleware"
 	"github.com/SaharKhamseh/cinema-backend/models"
 	"github.com/SaharKhamseh/cinema-backend/util"
 	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
 )
 
 // CreateBooking handles new ticket bookings
@@ -49,7 +52,7 @@ func CreateBooking(c *fiber.Ctx) error {
 
 	// Verify showtime exists and is in the future
 	var showTime models.ShowTime
-	if err := database.DB.First(&showTime, data["show_time_id"]).Error; err != nil {
+	if err := database.DB.Scopes(showTimesInChain(c)).First(&showTime, data["show_time_id"]).Error; err != nil {
 		return c.Status(404).JSON(fiber.Map{
 			"message": "Show time not found",
 		})
@@ -67,6 +70,67 @@ func CreateBooking(c *fiber.Ctx) error {
 	for i, id := range seatIDsInterface {
 		seatIDs[i] = uint(id.(float64))
 	}
+	seatIDs = uniqueIDs(seatIDs)
+
+	// Seats have to exist on the show's screen
+	var seats []models.Seat
+	database.DB.Where("id IN ? AND screen_id = ?", seatIDs, showTime.ScreenID).Find(&seats)
+	if len(seats) != len(seatIDs) {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "One or more selected seats do not exist on this screen",
+		})
+	}
+
+	// Blocked seats are off sale
+	blocked, err := blockedSeats(showTime)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to check seat blocks",
+			"error":   err.Error(),
+		})
+	}
+	for _, seat := range seats {
+		if block, ok := blocked[seat.ID]; ok {
+			return c.Status(400).JSON(fiber.Map{
+				"message": fmt.Sprintf("seat %s%d is blocked (%s)", seat.Row, seat.Number, block.Reason),
+			})
+		}
+	}
+
+	var screen models.Screen
+	database.DB.Unscoped().First(&screen, showTime.ScreenID)
+	seller := middleware.HasTheaterPermission(uint(userId), screen.TheaterID, models.PermSellTickets)
+
+	// Cashiers sell tickets at the box office on behalf of a customer
+	var customer models.User
+	if data["user_id"] != nil {
+		if !seller {
+			return c.Status(403).JSON(fiber.Map{
+				"message": "Access Denied",
+			})
+		}
+
+		if err := database.DB.Scopes(inChain(c)).First(&customer, data["user_id"]).Error; err != nil {
+			return c.Status(404).JSON(fiber.Map{
+				"message": "User not found",
+			})
+		}
+	} else if err := database.DB.First(&customer, userId).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"message": "User not found",
+		})
+	}
+	customerID := customer.Id
+
+	// Held accessible seats go to customers whose profile says they need
+	// them, or to whoever a cashier at the box office sells them to
+	override, _ := data["accessible"].(bool)
+	accessible := customer.AccessibleSeating || (seller && override)
+	if err := checkAccessibleSeats(showTime, seats, customerID, accessible); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
 
 	// Check if seats are available
 	var count int64
@@ -82,15 +146,16 @@ func CreateBooking(c *fiber.Ctx) error {
 		})
 	}
 
-	// Calculate total price
-	totalPrice := showTime.Price * float64(len(seatIDs))
+	// Calculate total price, including the format surcharge
+	totalPrice := (showTime.Price + showTime.Surcharge) * float64(len(seatIDs))
 
 	// Create booking
 	booking := models.Booking{
-		UserID:     uint(userId),
+		UserID:     customerID,
 		ShowTimeID: showTime.ID,
 		TotalPrice: totalPrice,
 		Status:     "pending",
+		Accessible: accessible,
 		BookedAt:   time.Now(),
 	}
 
@@ -120,15 +185,88 @@ func CreateBooking(c *fiber.Ctx) error {
 	tx.Commit()
 
 	// Load the complete booking with relationships
-	database.DB.Preload("User").Preload("ShowTime").Preload("Seats").First(&booking, booking.ID)
+	database.DB.Preload("User").Scopes(bookingHistory).First(&booking, booking.ID)
+	localizeMovies(c, &booking.ShowTime.Movie)
 
 	return c.Status(201).JSON(fiber.Map{
-		"message": "Booking created successfully",
-		"booking": booking,
+		"message":   "Booking created successfully",
+		"booking":   booking,
+		"screening": screeningLabel(booking.ShowTime),
 	})
 }
 
-// GetUserBookings returns all bookings for the logged-in user
+// checkAccessibleSeats enforces the hold on wheelchair spaces, companion
+// seats and easy access seats. Until the theater's release time before the
+// show they are only sold to customers who need accessible seating, and a
+// companion seat only together with its wheelchair space.
+func checkAccessibleSeats(showTime models.ShowTime, seats []models.Seat, userID uint, accessible bool) error {
+	var theater models.Theater
+	if err := database.DB.Unscoped().
+		Joins("JOIN screens ON screens.theater_id = theaters.id").
+		Where("screens.id = ?", showTime.ScreenID).
+		First(&theater).Error; err != nil {
+		return err
+	}
+
+	releaseAt := showTime.StartTime.Add(-time.Duration(theater.AccessibleReleaseMinutes) * time.Minute)
+	if !time.Now().Before(releaseAt) {
+		return nil
+	}
+
+	selected := map[uint]bool{}
+	for _, seat := range seats {
+		selected[seat.ID] = true
+	}
+
+	for _, seat := range seats {
+		if !seat.Wheelchair && !seat.Companion && !seat.EasyAccess {
+			continue
+		}
+		if !accessible {
+			return fmt.Errorf("seat %s%d is held for customers who need accessible seating until %s",
+				seat.Row, seat.Number, releaseAt.Format("2006-01-02 15:04"))
+		}
+		if !seat.Companion || seat.CompanionOfID == nil || selected[*seat.CompanionOfID] {
+			continue
+		}
+
+		// The wheelchair space may have been booked earlier by the same customer
+		var owned int64
+		database.DB.Model(&models.Booking{}).
+			Joins("JOIN booking_seats ON bookings.id = booking_seats.booking_id").
+			Where("bookings.show_time_id = ? AND bookings.user_id = ? AND bookings.status != ? AND booking_seats.seat_id = ?",
+				showTime.ID, userID, "cancelled", *seat.CompanionOfID).
+			Count(&owned)
+		if owned == 0 {
+			return fmt.Errorf("companion seat %s%d can only be booked with its wheelchair space", seat.Row, seat.Number)
+		}
+	}
+
+	return nil
+}
+
+// bookingHistory preloads the show, movie, screen and seats of bookings,
+// including shows, movies and screens that have since been deleted
+func bookingHistory(db *gorm.DB) *gorm.DB {
+	unscoped := func(db *gorm.DB) *gorm.DB {
+		return db.Unscoped()
+	}
+
+	return db.
+		Preload("ShowTime", unscoped).
+		Preload("ShowTime.Movie", unscoped).
+		Preload("ShowTime.Screen", unscoped).
+		Preload("ShowTime.Format").
+		Preload("Seats", unscoped)
+}
+
+// bookingSortFields whitelists the columns GetUserBookings may sort on
+var bookingSortFields = map[string]cursorField{
+	"booked_at":   {Column: "bookings.booked_at", Time: true},
+	"total_price": {Column: "bookings.total_price"},
+}
+
+// GetUserBookings returns a page of bookings for the logged-in user
 func GetUserBookings(c *fiber.Ctx) error {
 	cookie := c.Cookies("jwt")
 	userIdStr, err := util.Parsejwt(cookie)
@@ -146,21 +284,27 @@ func GetUserBookings(c *fiber.Ctx) error {
 		})
 	}
 
-	var bookings []models.Booking
-	if err := database.DB.
-		Preload("ShowTime.Movie").
-		Preload("ShowTime.Screen").
-		Preload("Seats").
-		Where("user_id = ?", uint(userId)).
-		Order("booked_at DESC").
-		Find(&bookings).Error; err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"message": "Failed to fetch bookings",
-			"error":   err.Error(),
-		})
+	query := database.DB.Model(&models.Booking{}).Where("user_id = ?", uint(userId))
+
+	bookings, meta, err := paginateCursor(c, query, bookingSortFields, "-booked_at",
+		func(booking models.Booking, field string) (uint, interface{}) {
+			if field == "total_price" {
+				return booking.ID, booking.TotalPrice
+			}
+			return booking.ID, booking.BookedAt
+		},
+		bookingHistory)
+	if err != nil {
+		return pageErrorResponse(c, err, "Failed to fetch bookings")
+	}
+
+	pointers := make([]*models.Movie, len(bookings))
+	for i := range bookings {
+		pointers[i] = &bookings[i].ShowTime.Movie
 	}
+	localizeMovies(c, pointers...)
 
-	return c.JSON(bookings)
+	return c.JSON(pageEnvelope(bookings, meta))
 }
 
 // GetBooking returns a specific booking
@@ -169,9 +313,7 @@ func GetBooking(c *fiber.Ctx) error {
 	var booking models.Booking
 
 	if err := database.DB.
-		Preload("ShowTime.Movie").
-		Preload("ShowTime.Screen").
-		Preload("Seats").
+		Scopes(bookingsInChain(c), bookingHistory).
 		First(&booking, id).Error; err != nil {
 		return c.Status(404).JSON(fiber.Map{
 			"message": "Booking not found",
@@ -188,12 +330,16 @@ func GetBooking(c *fiber.Ctx) error {
 		})
 	}
 
-	if booking.UserID != uint(userId) {
+	// Staff of the theater can look up any of its bookings
+	if booking.UserID != uint(userId) &&
+		!middleware.HasTheaterPermission(uint(userId), booking.ShowTime.Screen.TheaterID, models.PermViewBookings) {
 		return c.Status(403).JSON(fiber.Map{
 			"message": "Unauthorized to view this booking",
 		})
 	}
 
+	localizeMovies(c, &booking.ShowTime.Movie)
+
 	return c.JSON(booking)
 }
 
@@ -202,7 +348,7 @@ func CancelBooking(c *fiber.Ctx) error {
 	id := c.Params("id")
 	var booking models.Booking
 
-	if err := database.DB.First(&booking, id).Error; err != nil {
+	if err := database.DB.Scopes(bookingsInChain(c), bookingHistory).First(&booking, id).Error; err != nil {
 		return c.Status(404).JSON(fiber.Map{
 			"message": "Booking not found",
 		})
@@ -218,7 +364,8 @@ func CancelBooking(c *fiber.Ctx) error {
 		})
 	}
 
-	if booking.UserID != uint(userId) {
+	if booking.UserID != uint(userId) &&
+		!middleware.HasTheaterPermission(uint(userId), booking.ShowTime.Screen.TheaterID, models.PermCancelBookings) {
 		return c.Status(403).JSON(fiber.Map{
 			"message": "Unauthorized to view this booking",
 		})
@@ -231,10 +378,48 @@ func CancelBooking(c *fiber.Ctx) error {
 		})
 	}
 
-	booking.Status = "cancelled"
-	database.DB.Save(&booking)
+	if err := database.DB.Model(&booking).Updates(bookingCancellation()).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to cancel booking",
+			"error":   err.Error(),
+		})
+	}
 
 	return c.JSON(fiber.Map{
 		"message": "Booking cancelled successfully",
 	})
 }
+
+// bookingCancellation is the update that cancels bookings. Bookings can only
+// be cancelled before the show starts, so the whole price is refunded.
+func bookingCancellation() map[string]interface{} {
+	return map[string]interface{}{
+		"status":          "cancelled",
+		"refunded_amount": gorm.Expr("total_price"),
+	}
+}
+
+// GetShowTimeBookings lists the bookings of a show for theater staff, such
+// as ushers checking tickets at the door. Pass ?status= to filter.
+func GetShowTimeBookings(c *fiber.Ctx) error {
+	var showTime models.ShowTime
+	if err := database.DB.Scopes(showTimesInChain(c)).First(&showTime, c.Params("id")).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"message": "Show time not found",
+		})
+	}
+
+	query := database.DB.Model(&models.Booking{}).Where("show_time_id = ?", showTime.ID)
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var bookings []models.Booking
+	sortFields := map[string]string{"booked_at": "booked_at", "id": "id"}
+	meta, err := paginateOffset(c, query, &bookings, sortFields, "booked_at ASC", preload("User", "Seats"))
+	if err != nil {
+		return pageErrorResponse(c, err, "Failed to fetch bookings")
+	}
+
+	return c.JSON(pageEnvelope(bookings, meta))
+}
@@ -0,0 +1,288 @@
+package controller
+
+import (
+	"fmt"
+
+	"github.com/SaharKhamseh/cinema-backend/database"
+	"github.com/SaharKhamseh/cinema-backend/models"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// capacityError is a change that would give a theater's screens more seats
+// than the theater is licensed for
+type capacityError struct {
+	Licensed int
+	Seats    int
+}
+
+func (e *capacityError) Error() string {
+	return fmt.Sprintf("the theater is licensed for %d seats, its screens would have %d", e.Licensed, e.Seats)
+}
+
+// theaterSeatCount counts the seats on a theater's screens, leaving out
+// the screen ignoreScreenID
+func theaterSeatCount(db *gorm.DB, theaterID, ignoreScreenID uint) (int, error) {
+	var count int64
+	err := db.Model(&models.Seat{}).
+		Where("screen_id IN (SELECT id FROM screens WHERE theater_id = ? AND id <> ? AND deleted_at IS NULL)", theaterID, ignoreScreenID).
+		Count(&count).Error
+	return int(count), err
+}
+
+// checkLicensedCapacity refuses to give screen seats seats when the
+// theater's screens would then exceed its licensed capacity
+func checkLicensedCapacity(tx *gorm.DB, screen models.Screen, seats int) error {
+	var theater models.Theater
+	if err := tx.Unscoped().First(&theater, screen.TheaterID).Error; err != nil {
+		return err
+	}
+
+	others, err := theaterSeatCount(tx, theater.ID, screen.ID)
+	if err != nil {
+		return err
+	}
+	if others+seats > theater.Capacity {
+		return &capacityError{Licensed: theater.Capacity, Seats: others + seats}
+	}
+	return nil
+}
+
+// CapacityIssue is a mismatch found by the capacity integrity check
+type CapacityIssue struct {
+	Kind      string `json:"kind"` // screen_capacity, screen_layout, theater_capacity, duplicate_seat
+	TheaterID uint   `json:"theater_id"`
+	ScreenID  uint   `json:"screen_id,omitempty"`
+	Expected  int    `json:"expected"`
+	Actual    int    `json:"actual"`
+	Detail    string `json:"detail"`
+}
+
+// CheckCapacity compares the recorded capacities and layouts of the
+// theaters of a chain, or of every chain for chainID 0, with their seat
+// rows:
+//
+//   - screen_capacity: Screen.Capacity is not the number of seats
+//   - screen_layout: the stored layout places other seats than exist
+//   - theater_capacity: the screens have more seats than the theater is licensed for
+//   - duplicate_seat: two seats of a screen share a row and number
+func CheckCapacity(chainID uint) ([]CapacityIssue, error) {
+	issues := []CapacityIssue{}
+
+	query := database.DB.Order("id")
+	if chainID != 0 {
+		query = query.Where("chain_id = ?", chainID)
+	}
+	var theaters []models.Theater
+	if err := query.Find(&theaters).Error; err != nil {
+		return nil, err
+	}
+	if len(theaters) == 0 {
+		return issues, nil
+	}
+
+	theaterIDs := make([]uint, len(theaters))
+	for i, theater := range theaters {
+		theaterIDs[i] = theater.ID
+	}
+
+	var screens []models.Screen
+	if err := database.DB.Where("theater_id IN ?", theaterIDs).Order("id").Find(&screens).Error; err != nil {
+		return nil, err
+	}
+	screensByTheater := map[uint][]models.Screen{}
+	screenIDs := make([]uint, len(screens))
+	for i, screen := range screens {
+		screensByTheater[screen.TheaterID] = append(screensByTheater[screen.TheaterID], screen)
+		screenIDs[i] = screen.ID
+	}
+
+	var seats []models.Seat
+	if len(screenIDs) > 0 {
+		if err := database.DB.Where("screen_id IN ?", screenIDs).Order("id").Find(&seats).Error; err != nil {
+			return nil, err
+		}
+	}
+	seatsByScreen := map[uint][]models.Seat{}
+	for _, seat := range seats {
+		seatsByScreen[seat.ScreenID] = append(seatsByScreen[seat.ScreenID], seat)
+	}
+
+	for _, theater := range theaters {
+		total := 0
+		for _, screen := range screensByTheater[theater.ID] {
+			screenSeats := seatsByScreen[screen.ID]
+			total += len(screenSeats)
+
+			if screen.Capacity != len(screenSeats) {
+				issues = append(issues, CapacityIssue{
+					Kind:      "screen_capacity",
+					TheaterID: theater.ID,
+					ScreenID:  screen.ID,
+					Expected:  len(screenSeats),
+					Actual:    screen.Capacity,
+					Detail:    fmt.Sprintf("screen %q records capacity %d but has %d seats", screen.Name, screen.Capacity, len(screenSeats)),
+				})
+			}
+
+			existing := map[string]int{}
+			for _, seat := range screenSeats {
+				existing[fmt.Sprintf("%s%d", seat.Row, seat.Number)]++
+			}
+			reported := map[string]bool{}
+			for _, seat := range screenSeats {
+				label := fmt.Sprintf("%s%d", seat.Row, seat.Number)
+				if existing[label] > 1 && !reported[label] {
+					reported[label] = true
+					issues = append(issues, CapacityIssue{
+						Kind:      "duplicate_seat",
+						TheaterID: theater.ID,
+						ScreenID:  screen.ID,
+						Expected:  1,
+						Actual:    existing[label],
+						Detail:    fmt.Sprintf("screen %q has %d seats labelled %s", screen.Name, existing[label], label),
+					})
+				}
+			}
+
+			planned, err := buildSeats(screenLayout(screen))
+			matches := err == nil && len(planned) == len(screenSeats)
+			for _, seat := range planned {
+				if existing[fmt.Sprintf("%s%d", seat.Row, seat.Number)] == 0 {
+					matches = false
+				}
+			}
+			if !matches {
+				issues = append(issues, CapacityIssue{
+					Kind:      "screen_layout",
+					TheaterID: theater.ID,
+					ScreenID:  screen.ID,
+					Expected:  len(screenSeats),
+					Actual:    len(planned),
+					Detail:    fmt.Sprintf("the layout of screen %q does not match its seats", screen.Name),
+				})
+			}
+		}
+
+		if total > theater.Capacity {
+			issues = append(issues, CapacityIssue{
+				Kind:      "theater_capacity",
+				TheaterID: theater.ID,
+				Expected:  theater.Capacity,
+				Actual:    total,
+				Detail:    fmt.Sprintf("theater %q is licensed for %d seats but its screens have %d", theater.Name, theater.Capacity, total),
+			})
+		}
+	}
+
+	return issues, nil
+}
+
+// CapacityRepair is the outcome of RepairCapacity
+type CapacityRepair struct {
+	DryRun    bool            `json:"dry_run"`
+	Repaired  []CapacityIssue `json:"repaired"`
+	Remaining []CapacityIssue `json:"remaining"`
+}
+
+// RepairCapacity fixes the issues CheckCapacity finds that the seat rows
+// can settle: screen capacities and layouts are rewritten from the seats.
+// Theaters over their licensed capacity are only raised to their seat
+// count with raiseLicensed; duplicate seats are left for staff to resolve
+// since bookings may point at either. With dryRun nothing is saved.
+func RepairCapacity(chainID uint, raiseLicensed, dryRun bool) (CapacityRepair, error) {
+	repair := CapacityRepair{DryRun: dryRun, Repaired: []CapacityIssue{}, Remaining: []CapacityIssue{}}
+
+	issues, err := CheckCapacity(chainID)
+	if err != nil {
+		return repair, err
+	}
+
+	tx := database.DB.Begin()
+	if tx.Error != nil {
+		return repair, tx.Error
+	}
+
+	synced := map[uint]bool{}
+	for _, issue := range issues {
+		switch {
+		case issue.Kind == "screen_capacity" || issue.Kind == "screen_layout":
+			if !synced[issue.ScreenID] {
+				if err := repairScreen(tx, issue); err != nil {
+					tx.Rollback()
+					return repair, err
+				}
+				synced[issue.ScreenID] = true
+			}
+			repair.Repaired = append(repair.Repaired, issue)
+
+		case issue.Kind == "theater_capacity" && raiseLicensed:
+			var theater models.Theater
+			if err := tx.First(&theater, issue.TheaterID).Error; err != nil {
+				tx.Rollback()
+				return repair, err
+			}
+			before := theater
+			theater.Capacity = issue.Actual
+			if err := tx.Model(&theater).Update("capacity", theater.Capacity).Error; err != nil {
+				tx.Rollback()
+				return repair, err
+			}
+			if err := writeAudit(tx, theater.ChainID, 0, "update", "theater", theater.ID, before, theater); err != nil {
+				tx.Rollback()
+				return repair, err
+			}
+			repair.Repaired = append(repair.Repaired, issue)
+
+		default:
+			repair.Remaining = append(repair.Remaining, issue)
+		}
+	}
+
+	if dryRun {
+		tx.Rollback()
+		return repair, nil
+	}
+	return repair, tx.Commit().Error
+}
+
+// repairScreen rewrites a screen's capacity and layout from its seats
+func repairScreen(tx *gorm.DB, issue CapacityIssue) error {
+	var screen models.Screen
+	if err := tx.First(&screen, issue.ScreenID).Error; err != nil {
+		return err
+	}
+	before := screen
+
+	if err := syncLayoutFromSeats(tx, &screen); err != nil {
+		return err
+	}
+
+	var theater models.Theater
+	if err := tx.Unscoped().First(&theater, screen.TheaterID).Error; err != nil {
+		return err
+	}
+	return writeAudit(tx, theater.ChainID, 0, "update", "screen", screen.ID, before, screen)
+}
+
+// GetCapacityReport lists the capacity mismatches of the chain's theaters.
+// Run the repair-capacity command to fix them.
+func GetCapacityReport(c *fiber.Ctx) error {
+	issues, err := CheckCapacity(chainID(c))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to check capacities",
+			"error":   err.Error(),
+		})
+	}
+
+	counts := map[string]int{}
+	for _, issue := range issues {
+		counts[issue.Kind]++
+	}
+
+	return c.JSON(fiber.Map{
+		"data":   issues,
+		"counts": counts,
+	})
+}
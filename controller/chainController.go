@@ -0,0 +1,250 @@
+package controller
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/SaharKhamseh/cinema-backend/database"
+	"github.com/SaharKhamseh/cinema-backend/models"
+	"github.com/SaharKhamseh/cinema-backend/util"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// chainID is the chain the request was resolved to by middleware.ResolveChain
+func chainID(c *fiber.Ctx) uint {
+	id, _ := c.Locals("chain_id").(uint)
+	if id == 0 {
+		return database.DefaultChainID
+	}
+	return id
+}
+
+// inChain scopes a query on a table with a chain_id column, such as
+// theaters, movies, formats, genres, tags, people and users
+func inChain(c *fiber.Ctx) func(*gorm.DB) *gorm.DB {
+	id := chainID(c)
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: "chain_id"}, Value: id})
+	}
+}
+
+// ownedThrough scopes a query on a table that belongs to a chain through
+// column, which has to be one of the ids selected by owners
+func ownedThrough(c *fiber.Ctx, column, owners string) func(*gorm.DB) *gorm.DB {
+	id := chainID(c)
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("? IN ("+owners+")", clause.Column{Table: clause.CurrentTable, Name: column}, id)
+	}
+}
+
+const (
+	chainTheaters  = "SELECT id FROM theaters WHERE chain_id = ?"
+	chainMovies    = "SELECT id FROM movies WHERE chain_id = ?"
+	chainScreens   = "SELECT screens.id FROM screens JOIN theaters ON theaters.id = screens.theater_id WHERE theaters.chain_id = ?"
+	chainShowTimes = "SELECT show_times.id FROM show_times JOIN movies ON movies.id = show_times.movie_id WHERE movies.chain_id = ?"
+)
+
+// screensInChain scopes screens to the chain's theaters
+func screensInChain(c *fiber.Ctx) func(*gorm.DB) *gorm.DB {
+	return ownedThrough(c, "theater_id", chainTheaters)
+}
+
+// seatsInChain scopes seats to the screens of the chain's theaters
+func seatsInChain(c *fiber.Ctx) func(*gorm.DB) *gorm.DB {
+	return ownedThrough(c, "screen_id", chainScreens)
+}
+
+// showTimesInChain scopes show times to the chain's movies. Show times can
+// only be scheduled on screens of the same chain.
+func showTimesInChain(c *fiber.Ctx) func(*gorm.DB) *gorm.DB {
+	return ownedThrough(c, "movie_id", chainMovies)
+}
+
+// bookingsInChain scopes bookings to the chain's show times
+func bookingsInChain(c *fiber.Ctx) func(*gorm.DB) *gorm.DB {
+	return ownedThrough(c, "show_time_id", chainShowTimes)
+}
+
+// movieDataInChain scopes reviews, credits, translations and media, which
+// belong to a chain through their movie
+func movieDataInChain(c *fiber.Ctx) func(*gorm.DB) *gorm.DB {
+	return ownedThrough(c, "movie_id", chainMovies)
+}
+
+// GetChains lists every chain of the deployment
+func GetChains(c *fiber.Ctx) error {
+	var chains []models.Chain
+	if err := database.DB.Order("id").Find(&chains).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to fetch chains",
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(chains)
+}
+
+// applyChainDetails sets the name, slug and domain present in a request body
+func applyChainDetails(chain *models.Chain, data map[string]interface{}) error {
+	if value, ok := data["name"]; ok {
+		name, _ := value.(string)
+		if strings.TrimSpace(name) == "" {
+			return fmt.Errorf("name cannot be empty")
+		}
+		chain.Name = strings.TrimSpace(name)
+		if data["slug"] == nil && chain.Slug == "" {
+			chain.Slug = util.Slugify(chain.Name)
+		}
+	}
+
+	if value, ok := data["slug"]; ok {
+		slug, _ := value.(string)
+		if chain.Slug = util.Slugify(slug); chain.Slug == "" {
+			return fmt.Errorf("slug cannot be empty")
+		}
+	}
+
+	if value, ok := data["domain"]; ok {
+		domain, _ := value.(string)
+		if domain = strings.ToLower(strings.TrimSpace(domain)); domain == "" {
+			chain.Domain = nil
+		} else {
+			chain.Domain = &domain
+		}
+	}
+
+	return nil
+}
+
+// chainConflict reports whether another chain already uses the slug or
+// domain of chain
+func chainConflict(chain models.Chain) bool {
+	query := database.DB.Model(&models.Chain{}).Where("id <> ?", chain.ID)
+	if chain.Domain != nil {
+		query = query.Where("slug = ? OR domain = ?", chain.Slug, *chain.Domain)
+	} else {
+		query = query.Where("slug = ?", chain.Slug)
+	}
+
+	var count int64
+	query.Count(&count)
+	return count > 0
+}
+
+// CreateChain adds a chain with its own copy of the default formats
+func CreateChain(c *fiber.Ctx) error {
+	var data map[string]interface{}
+	if err := c.BodyParser(&data); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Invalid request body",
+		})
+	}
+
+	if data["name"] == nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "name is required",
+		})
+	}
+
+	var chain models.Chain
+	if err := applyChainDetails(&chain, data); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	if chainConflict(chain) {
+		return c.Status(409).JSON(fiber.Map{
+			"message": "A chain with this slug or domain already exists",
+		})
+	}
+
+	tx := database.DB.Begin()
+
+	if err := tx.Create(&chain).Error; err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to create chain",
+			"error":   err.Error(),
+		})
+	}
+
+	if err := recordAudit(tx, c, "create", "chain", chain.ID, nil, chain); err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to create chain",
+			"error":   err.Error(),
+		})
+	}
+
+	tx.Commit()
+
+	if err := database.SeedFormats(chain.ID); err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Chain created but its formats could not be added",
+			"error":   err.Error(),
+		})
+	}
+
+	return c.Status(201).JSON(fiber.Map{
+		"message": "Chain created successfully",
+		"chain":   chain,
+	})
+}
+
+// UpdateChain renames a chain or changes the host name it is served on
+func UpdateChain(c *fiber.Ctx) error {
+	var chain models.Chain
+	if err := database.DB.First(&chain, c.Params("id")).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"message": "Chain not found",
+		})
+	}
+	before := chain
+
+	var data map[string]interface{}
+	if err := c.BodyParser(&data); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Invalid request body",
+		})
+	}
+
+	if err := applyChainDetails(&chain, data); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	if chainConflict(chain) {
+		return c.Status(409).JSON(fiber.Map{
+			"message": "A chain with this slug or domain already exists",
+		})
+	}
+
+	tx := database.DB.Begin()
+
+	if err := tx.Save(&chain).Error; err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to update chain",
+			"error":   err.Error(),
+		})
+	}
+
+	if err := recordAudit(tx, c, "update", "chain", chain.ID, before, chain); err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to update chain",
+			"error":   err.Error(),
+		})
+	}
+
+	tx.Commit()
+
+	return c.JSON(fiber.Map{
+		"message": "Chain updated successfully",
+		"chain":   chain,
+	})
+}
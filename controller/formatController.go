@@ -0,0 +1,337 @@
+package controller
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/SaharKhamseh/cinema-backend/database"
+	"github.com/SaharKhamseh/cinema-backend/models"
+	"github.com/SaharKhamseh/cinema-backend/util"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// GetFormats returns the projection formats
+func GetFormats(c *fiber.Ctx) error {
+	var formats []models.Format
+
+	if err := database.DB.Scopes(inChain(c)).Order("name").Find(&formats).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to fetch formats",
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(formats)
+}
+
+// CreateFormat adds a projection format
+func CreateFormat(c *fiber.Ctx) error {
+	var data map[string]interface{}
+
+	if err := c.BodyParser(&data); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Invalid request body",
+		})
+	}
+
+	name, _ := data["name"].(string)
+	name = strings.TrimSpace(name)
+	code, _ := data["code"].(string)
+	if code == "" {
+		code = name
+	}
+	code = util.Slugify(code)
+	if name == "" || code == "" {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "name is required",
+		})
+	}
+
+	surcharge, err := parseSurcharge(data["surcharge"])
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	var existing models.Format
+	if err := database.DB.Scopes(inChain(c)).Where("code = ?", code).First(&existing).Error; err == nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Format already exists",
+			"format":  existing,
+		})
+	}
+
+	format := models.Format{ChainID: chainID(c), Code: code, Name: name, Surcharge: surcharge}
+
+	tx := database.DB.Begin()
+
+	if err := tx.Create(&format).Error; err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to create format",
+			"error":   err.Error(),
+		})
+	}
+
+	if err := recordAudit(tx, c, "create", "format", format.ID, nil, format); err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to create format",
+			"error":   err.Error(),
+		})
+	}
+
+	tx.Commit()
+
+	return c.Status(201).JSON(fiber.Map{
+		"message": "Format created successfully",
+		"format":  format,
+	})
+}
+
+// UpdateFormat renames a format or changes its surcharge. Shows that are
+// already scheduled keep the surcharge they were created with.
+func UpdateFormat(c *fiber.Ctx) error {
+	id := c.Params("id")
+	var format models.Format
+
+	if err := database.DB.Scopes(inChain(c)).First(&format, id).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"message": "Format not found",
+		})
+	}
+
+	var data map[string]interface{}
+	if err := c.BodyParser(&data); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Invalid request body",
+		})
+	}
+
+	before := format
+
+	if name, ok := data["name"].(string); ok && strings.TrimSpace(name) != "" {
+		format.Name = strings.TrimSpace(name)
+	}
+	if data["surcharge"] != nil {
+		surcharge, err := parseSurcharge(data["surcharge"])
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+		format.Surcharge = surcharge
+	}
+
+	tx := database.DB.Begin()
+
+	if err := tx.Save(&format).Error; err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to update format",
+			"error":   err.Error(),
+		})
+	}
+
+	if err := recordAudit(tx, c, "update", "format", format.ID, before, format); err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to update format",
+			"error":   err.Error(),
+		})
+	}
+
+	tx.Commit()
+
+	return c.JSON(fiber.Map{
+		"message": "Format updated successfully",
+		"format":  format,
+	})
+}
+
+// DeleteFormat removes a format that no show uses and unlinks it from screens
+func DeleteFormat(c *fiber.Ctx) error {
+	id := c.Params("id")
+	var format models.Format
+
+	if err := database.DB.Scopes(inChain(c)).First(&format, id).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"message": "Format not found",
+		})
+	}
+
+	var shows int64
+	database.DB.Unscoped().Model(&models.ShowTime{}).Where("format_id = ?", format.ID).Count(&shows)
+	if shows > 0 {
+		return c.Status(409).JSON(fiber.Map{
+			"message": "Format is used by show times",
+		})
+	}
+
+	tx := database.DB.Begin()
+
+	if err := tx.Exec("DELETE FROM screen_formats WHERE format_id = ?", format.ID).Error; err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to delete format",
+			"error":   err.Error(),
+		})
+	}
+
+	if err := tx.Delete(&format).Error; err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to delete format",
+			"error":   err.Error(),
+		})
+	}
+
+	if err := recordAudit(tx, c, "delete", "format", format.ID, format, nil); err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to delete format",
+			"error":   err.Error(),
+		})
+	}
+
+	tx.Commit()
+
+	return c.JSON(fiber.Map{
+		"message": "Format deleted successfully",
+	})
+}
+
+// SetScreenFormats replaces the formats a screen can project. A format
+// cannot be removed while future shows on the screen use it.
+func SetScreenFormats(c *fiber.Ctx) error {
+	id := c.Params("id")
+	var screen models.Screen
+
+	if err := database.DB.Scopes(screensInChain(c)).Preload("Formats").First(&screen, id).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"message": "Screen not found",
+		})
+	}
+
+	var data map[string]interface{}
+	if err := c.BodyParser(&data); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Invalid request body",
+		})
+	}
+
+	if data["format_ids"] == nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "format_ids is required",
+		})
+	}
+
+	formats, err := findFormats(database.DB.Scopes(inChain(c)), data["format_ids"])
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	keep := make([]uint, len(formats))
+	for i, format := range formats {
+		keep[i] = format.ID
+	}
+
+	var stranded int64
+	query := database.DB.Model(&models.ShowTime{}).
+		Where("screen_id = ? AND start_time > ?", screen.ID, time.Now())
+	if len(keep) > 0 {
+		query = query.Where("format_id NOT IN ?", keep)
+	}
+	query.Count(&stranded)
+	if stranded > 0 {
+		return c.Status(409).JSON(fiber.Map{
+			"message": "Future show times on this screen use a format being removed",
+		})
+	}
+
+	before := screen
+
+	tx := database.DB.Begin()
+
+	if err := tx.Model(&screen).Association("Formats").Replace(formats); err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to update screen formats",
+			"error":   err.Error(),
+		})
+	}
+	screen.Formats = formats
+
+	if err := recordAudit(tx, c, "update", "screen", screen.ID, before, screen); err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to update screen formats",
+			"error":   err.Error(),
+		})
+	}
+
+	tx.Commit()
+
+	return c.JSON(fiber.Map{
+		"message": "Screen formats updated successfully",
+		"screen":  screen,
+	})
+}
+
+// findFormats loads the formats for an array of ids, failing on unknown ids
+func findFormats(db *gorm.DB, value interface{}) ([]models.Format, error) {
+	ids, err := idList(value)
+	if err != nil {
+		return nil, err
+	}
+
+	formats := []models.Format{}
+	if len(ids) == 0 {
+		return formats, nil
+	}
+
+	if err := db.Where("id IN ?", ids).Find(&formats).Error; err != nil {
+		return nil, err
+	}
+	if len(formats) != len(uniqueIDs(ids)) {
+		return nil, fmt.Errorf("one or more formats do not exist")
+	}
+	return formats, nil
+}
+
+// screenSupports reports whether a screen can project a format
+func screenSupports(screenID, formatID uint) bool {
+	var count int64
+	database.DB.Table("screen_formats").
+		Where("screen_id = ? AND format_id = ?", screenID, formatID).
+		Count(&count)
+	return count > 0
+}
+
+func parseSurcharge(value interface{}) (float64, error) {
+	if value == nil {
+		return 0, nil
+	}
+	surcharge, ok := value.(float64)
+	if !ok || surcharge < 0 {
+		return 0, fmt.Errorf("surcharge must be a non-negative number")
+	}
+	return surcharge, nil
+}
+
+func uniqueIDs(ids []uint) []uint {
+	seen := map[uint]bool{}
+	unique := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,547 @@
+package controller
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/SaharKhamseh/cinema-backend/database"
+	"github.com/SaharKhamseh/cinema-backend/models"
+	"github.com/SaharKhamseh/cinema-backend/util"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// termName reads the name of a genre or tag from a request body and
+// returns it with its slug
+func termName(data map[string]interface{}) (string, string) {
+	name, _ := data["name"].(string)
+	name = strings.TrimSpace(name)
+	return name, util.Slugify(name)
+}
+
+// genreMovies lists the movies linked to a genre
+func genreMovies(tx *gorm.DB, genreID uint) ([]uint, error) {
+	var movieIDs []uint
+	err := tx.Table("movie_genres").Where("genre_id = ?", genreID).Pluck("movie_id", &movieIDs).Error
+	return movieIDs, err
+}
+
+// GetGenres returns the genre taxonomy
+func GetGenres(c *fiber.Ctx) error {
+	var genres []models.Genre
+
+	if err := database.DB.Scopes(inChain(c)).Order("name").Find(&genres).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to fetch genres",
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(genres)
+}
+
+// CreateGenre adds a genre to the taxonomy
+func CreateGenre(c *fiber.Ctx) error {
+	var data map[string]interface{}
+
+	if err := c.BodyParser(&data); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Invalid request body",
+		})
+	}
+
+	name, slug := termName(data)
+	if slug == "" {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "name is required",
+		})
+	}
+
+	var existing models.Genre
+	if err := database.DB.Scopes(inChain(c)).Where("slug = ?", slug).First(&existing).Error; err == nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Genre already exists",
+			"genre":   existing,
+		})
+	}
+
+	genre := models.Genre{ChainID: chainID(c), Name: name, Slug: slug}
+
+	tx := database.DB.Begin()
+
+	if err := tx.Create(&genre).Error; err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to create genre",
+			"error":   err.Error(),
+		})
+	}
+
+	if err := recordAudit(tx, c, "create", "genre", genre.ID, nil, genre); err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to create genre",
+			"error":   err.Error(),
+		})
+	}
+
+	tx.Commit()
+
+	return c.Status(201).JSON(fiber.Map{
+		"message": "Genre created successfully",
+		"genre":   genre,
+	})
+}
+
+// UpdateGenre renames a genre and the genre string of its movies
+func UpdateGenre(c *fiber.Ctx) error {
+	id := c.Params("id")
+	var genre models.Genre
+
+	if err := database.DB.Scopes(inChain(c)).First(&genre, id).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"message": "Genre not found",
+		})
+	}
+
+	var data map[string]interface{}
+	if err := c.BodyParser(&data); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Invalid request body",
+		})
+	}
+
+	name, slug := termName(data)
+	if slug == "" {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "name is required",
+		})
+	}
+
+	var count int64
+	database.DB.Model(&models.Genre{}).Scopes(inChain(c)).Where("slug = ? AND id <> ?", slug, genre.ID).Count(&count)
+	if count > 0 {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Another genre already uses this name",
+		})
+	}
+
+	before := genre
+	genre.Name = name
+	genre.Slug = slug
+
+	tx := database.DB.Begin()
+
+	if err := tx.Save(&genre).Error; err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to update genre",
+			"error":   err.Error(),
+		})
+	}
+
+	movieIDs, err := genreMovies(tx, genre.ID)
+	if err == nil {
+		err = refreshMovieGenres(tx, movieIDs)
+	}
+	if err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to update genre",
+			"error":   err.Error(),
+		})
+	}
+
+	if err := recordAudit(tx, c, "update", "genre", genre.ID, before, genre); err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to update genre",
+			"error":   err.Error(),
+		})
+	}
+
+	tx.Commit()
+
+	return c.JSON(fiber.Map{
+		"message": "Genre updated successfully",
+		"genre":   genre,
+	})
+}
+
+// DeleteGenre removes a genre and unlinks it from its movies
+func DeleteGenre(c *fiber.Ctx) error {
+	id := c.Params("id")
+	var genre models.Genre
+
+	if err := database.DB.Scopes(inChain(c)).First(&genre, id).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"message": "Genre not found",
+		})
+	}
+
+	tx := database.DB.Begin()
+
+	movieIDs, err := genreMovies(tx, genre.ID)
+	if err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to delete genre",
+			"error":   err.Error(),
+		})
+	}
+
+	if err := tx.Exec("DELETE FROM movie_genres WHERE genre_id = ?", genre.ID).Error; err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to delete genre",
+			"error":   err.Error(),
+		})
+	}
+
+	if err := tx.Delete(&genre).Error; err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to delete genre",
+			"error":   err.Error(),
+		})
+	}
+
+	if err := refreshMovieGenres(tx, movieIDs); err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to delete genre",
+			"error":   err.Error(),
+		})
+	}
+
+	if err := recordAudit(tx, c, "delete", "genre", genre.ID, genre, nil); err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to delete genre",
+			"error":   err.Error(),
+		})
+	}
+
+	tx.Commit()
+
+	return c.JSON(fiber.Map{
+		"message": "Genre deleted successfully",
+	})
+}
+
+// GetTags returns all movie tags
+func GetTags(c *fiber.Ctx) error {
+	var tags []models.Tag
+
+	if err := database.DB.Scopes(inChain(c)).Order("name").Find(&tags).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to fetch tags",
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(tags)
+}
+
+// CreateTag adds a tag to the taxonomy
+func CreateTag(c *fiber.Ctx) error {
+	var data map[string]interface{}
+
+	if err := c.BodyParser(&data); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Invalid request body",
+		})
+	}
+
+	name, slug := termName(data)
+	if slug == "" {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "name is required",
+		})
+	}
+
+	var existing models.Tag
+	if err := database.DB.Scopes(inChain(c)).Where("slug = ?", slug).First(&existing).Error; err == nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Tag already exists",
+			"tag":     existing,
+		})
+	}
+
+	tag := models.Tag{ChainID: chainID(c), Name: name, Slug: slug}
+
+	tx := database.DB.Begin()
+
+	if err := tx.Create(&tag).Error; err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to create tag",
+			"error":   err.Error(),
+		})
+	}
+
+	if err := recordAudit(tx, c, "create", "tag", tag.ID, nil, tag); err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to create tag",
+			"error":   err.Error(),
+		})
+	}
+
+	tx.Commit()
+
+	return c.Status(201).JSON(fiber.Map{
+		"message": "Tag created successfully",
+		"tag":     tag,
+	})
+}
+
+// UpdateTag renames a tag
+func UpdateTag(c *fiber.Ctx) error {
+	id := c.Params("id")
+	var tag models.Tag
+
+	if err := database.DB.Scopes(inChain(c)).First(&tag, id).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"message": "Tag not found",
+		})
+	}
+
+	var data map[string]interface{}
+	if err := c.BodyParser(&data); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Invalid request body",
+		})
+	}
+
+	name, slug := termName(data)
+	if slug == "" {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "name is required",
+		})
+	}
+
+	var count int64
+	database.DB.Model(&models.Tag{}).Scopes(inChain(c)).Where("slug = ? AND id <> ?", slug, tag.ID).Count(&count)
+	if count > 0 {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Another tag already uses this name",
+		})
+	}
+
+	before := tag
+	tag.Name = name
+	tag.Slug = slug
+
+	tx := database.DB.Begin()
+
+	if err := tx.Save(&tag).Error; err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to update tag",
+			"error":   err.Error(),
+		})
+	}
+
+	if err := recordAudit(tx, c, "update", "tag", tag.ID, before, tag); err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to update tag",
+			"error":   err.Error(),
+		})
+	}
+
+	tx.Commit()
+
+	return c.JSON(fiber.Map{
+		"message": "Tag updated successfully",
+		"tag":     tag,
+	})
+}
+
+// DeleteTag removes a tag and unlinks it from its movies
+func DeleteTag(c *fiber.Ctx) error {
+	id := c.Params("id")
+	var tag models.Tag
+
+	if err := database.DB.Scopes(inChain(c)).First(&tag, id).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"message": "Tag not found",
+		})
+	}
+
+	tx := database.DB.Begin()
+
+	if err := tx.Exec("DELETE FROM movie_tags WHERE tag_id = ?", tag.ID).Error; err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to delete tag",
+			"error":   err.Error(),
+		})
+	}
+
+	if err := tx.Delete(&tag).Error; err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to delete tag",
+			"error":   err.Error(),
+		})
+	}
+
+	if err := recordAudit(tx, c, "delete", "tag", tag.ID, tag, nil); err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to delete tag",
+			"error":   err.Error(),
+		})
+	}
+
+	tx.Commit()
+
+	return c.JSON(fiber.Map{
+		"message": "Tag deleted successfully",
+	})
+}
+
+// idList converts a JSON array of numbers from a request body into ids
+func idList(value interface{}) ([]uint, error) {
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected an array of ids")
+	}
+
+	ids := make([]uint, 0, len(items))
+	for _, item := range items {
+		number, ok := item.(float64)
+		if !ok || number <= 0 {
+			return nil, fmt.Errorf("expected an array of ids")
+		}
+		ids = append(ids, uint(number))
+	}
+	return ids, nil
+}
+
+// setMovieTaxonomy replaces a movie's genres and tags when they are present
+// in the request body. Genres are given as genre_ids, or as a free-text
+// genre whose names are matched to the chain's genres, creating missing
+// ones. Either way the legacy genre string is rewritten from the linked
+// genres.
+func setMovieTaxonomy(tx *gorm.DB, movie *models.Movie, data map[string]interface{}) error {
+	if data["genre_ids"] != nil {
+		ids, err := idList(data["genre_ids"])
+		if err != nil {
+			return fmt.Errorf("genre_ids: %w", err)
+		}
+
+		var genres []models.Genre
+		if len(ids) > 0 {
+			if err := tx.Where("chain_id = ? AND id IN ?", movie.ChainID, ids).Find(&genres).Error; err != nil {
+				return err
+			}
+			if len(genres) != len(ids) {
+				return fmt.Errorf("genre_ids: one or more genres not found")
+			}
+		}
+		if err := linkGenres(tx, movie, genres); err != nil {
+			return err
+		}
+	} else if data["genre"] != nil {
+		value, ok := data["genre"].(string)
+		if !ok {
+			return fmt.Errorf("genre must be a string")
+		}
+		genres, err := resolveGenres(tx, movie.ChainID, value)
+		if err != nil {
+			return err
+		}
+		if err := linkGenres(tx, movie, genres); err != nil {
+			return err
+		}
+	}
+
+	if data["tag_ids"] != nil {
+		ids, err := idList(data["tag_ids"])
+		if err != nil {
+			return fmt.Errorf("tag_ids: %w", err)
+		}
+
+		var tags []models.Tag
+		if len(ids) > 0 {
+			if err := tx.Where("chain_id = ? AND id IN ?", movie.ChainID, ids).Find(&tags).Error; err != nil {
+				return err
+			}
+			if len(tags) != len(ids) {
+				return fmt.Errorf("tag_ids: one or more tags not found")
+			}
+		}
+		if err := tx.Model(movie).Association("Tags").Replace(tags); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// resolveGenres finds the chain's genres named in a free-text genre such as
+// "Action/Comedy", matching them by slug and creating missing ones
+func resolveGenres(tx *gorm.DB, chainID uint, value string) ([]models.Genre, error) {
+	var genres []models.Genre
+	seen := map[string]bool{}
+	for _, name := range util.SplitGenres(value) {
+		slug := util.Slugify(name)
+		if slug == "" || seen[slug] {
+			continue
+		}
+		seen[slug] = true
+
+		genre := models.Genre{ChainID: chainID, Name: name, Slug: slug}
+		if err := tx.Where(models.Genre{ChainID: chainID, Slug: slug}).FirstOrCreate(&genre).Error; err != nil {
+			return nil, err
+		}
+		genres = append(genres, genre)
+	}
+	return genres, nil
+}
+
+// linkGenres replaces the genres of a movie and rewrites its legacy genre
+// string from them
+func linkGenres(tx *gorm.DB, movie *models.Movie, genres []models.Genre) error {
+	if err := tx.Model(movie).Association("Genres").Replace(genres); err != nil {
+		return err
+	}
+
+	movie.Genre = genreString(genres)
+	return tx.Model(movie).Update("genre", movie.Genre).Error
+}
+
+// genreString joins genre names in name order, as the legacy genre string
+func genreString(genres []models.Genre) string {
+	names := make([]string, len(genres))
+	for i, genre := range genres {
+		names[i] = genre.Name
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// refreshMovieGenres rewrites the legacy genre string of the movies from the
+// names of their linked genres
+func refreshMovieGenres(tx *gorm.DB, movieIDs []uint) error {
+	if len(movieIDs) == 0 {
+		return nil
+	}
+
+	var movies []models.Movie
+	if err := tx.Unscoped().Preload("Genres").Where("id IN ?", movieIDs).Find(&movies).Error; err != nil {
+		return err
+	}
+
+	for _, movie := range movies {
+		if err := tx.Unscoped().Model(&models.Movie{}).Where("id = ?", movie.ID).
+			Update("genre", genreString(movie.Genres)).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
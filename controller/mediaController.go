@@ -0,0 +1,352 @@
+package controller
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"net/http"
+
+	"github.com/SaharKhamseh/cinema-backend/database"
+	"github.com/SaharKhamseh/cinema-backend/models"
+	"github.com/SaharKhamseh/cinema-backend/storage"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// mediaRule describes what may be uploaded for one kind of movie asset
+type mediaRule struct {
+	ContentTypes map[string]string // content type -> file extension
+	MaxSize      int64
+	Variants     map[string]int // variant name -> width in pixels
+}
+
+var mediaRules = map[string]mediaRule{
+	"poster": {
+		ContentTypes: map[string]string{"image/jpeg": ".jpg", "image/png": ".png"},
+		MaxSize:      10 << 20,
+		Variants:     map[string]int{"small": 185, "medium": 342, "large": 780},
+	},
+	"backdrop": {
+		ContentTypes: map[string]string{"image/jpeg": ".jpg", "image/png": ".png"},
+		MaxSize:      20 << 20,
+		Variants:     map[string]int{"small": 300, "medium": 780, "large": 1280},
+	},
+	"trailer": {
+		ContentTypes: map[string]string{"video/mp4": ".mp4", "video/webm": ".webm"},
+		MaxSize:      500 << 20,
+	},
+}
+
+// mediaFormOverhead is the room left in an upload request for the multipart
+// headers around the file
+const mediaFormOverhead = 1 << 20
+
+// maxImageSide and maxImagePixels bound the dimensions an uploaded image
+// may declare, so a small file cannot make the decoder allocate gigabytes.
+// 40 megapixels decode to about 160 MB and still fit an 8K backdrop.
+const (
+	maxImageSide   = 10000
+	maxImagePixels = 40000000
+)
+
+// UploadMovieMedia stores a poster, backdrop or trailer for a movie. The
+// content type is sniffed from the file itself rather than trusted from the
+// request, and images get resized JPEG variants next to the original.
+func UploadMovieMedia(c *fiber.Ctx) error {
+	kind := c.Params("kind")
+	rule, ok := mediaRules[kind]
+	if !ok {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Unknown media kind. Use poster, backdrop or trailer",
+		})
+	}
+
+	// Uploads are exempt from the server's body limit, so the request is
+	// held to the limit of its kind. Bodies of unknown length are refused as
+	// they would be read whole before the file size could be checked.
+	length := c.Request().Header.ContentLength()
+	if length < 0 {
+		return c.Status(411).JSON(fiber.Map{
+			"message": "Uploads must send a Content-Length",
+		})
+	}
+	if int64(length) > rule.MaxSize+mediaFormOverhead {
+		return c.Status(413).JSON(fiber.Map{
+			"message": fmt.Sprintf("File is too large. Maximum size is %d MB", rule.MaxSize>>20),
+		})
+	}
+
+	id := c.Params("id")
+	var movie models.Movie
+
+	if err := database.DB.Scopes(inChain(c)).First(&movie, id).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"message": "Movie not found",
+		})
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "file is required",
+		})
+	}
+
+	if fileHeader.Size > rule.MaxSize {
+		return c.Status(400).JSON(fiber.Map{
+			"message": fmt.Sprintf("File is too large. Maximum size is %d MB", rule.MaxSize>>20),
+		})
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Unable to read uploaded file",
+		})
+	}
+	defer file.Close()
+
+	head := make([]byte, 512)
+	n, _ := io.ReadFull(file, head)
+	head = head[:n]
+
+	contentType := http.DetectContentType(head)
+	extension, ok := rule.ContentTypes[contentType]
+	if !ok {
+		return c.Status(400).JSON(fiber.Map{
+			"message": fmt.Sprintf("Unsupported file type %s for %s", contentType, kind),
+		})
+	}
+
+	content := io.MultiReader(bytes.NewReader(head), file)
+	prefix := fmt.Sprintf("movies/%d/%s/%s", movie.ID, kind, uuid.NewString())
+
+	var assets []models.MediaAsset
+	var savedKeys []string
+
+	// cleanup removes files written so far when the upload fails part way
+	cleanup := func() {
+		for _, key := range savedKeys {
+			storage.Media.Delete(key)
+		}
+	}
+
+	if rule.Variants == nil {
+		key := prefix + "/original" + extension
+		url, err := storage.Media.Save(key, content)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{
+				"message": "Failed to store file",
+				"error":   err.Error(),
+			})
+		}
+		savedKeys = append(savedKeys, key)
+		assets = append(assets, models.MediaAsset{
+			MovieID:     movie.ID,
+			Kind:        kind,
+			Variant:     "original",
+			StorageKey:  key,
+			URL:         url,
+			ContentType: contentType,
+			Size:        fileHeader.Size,
+		})
+	} else {
+		raw, err := io.ReadAll(content)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"message": "Unable to read uploaded file",
+			})
+		}
+
+		config, _, err := image.DecodeConfig(bytes.NewReader(raw))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"message": "File is not a valid image",
+			})
+		}
+		if config.Width > maxImageSide || config.Height > maxImageSide {
+			return c.Status(400).JSON(fiber.Map{
+				"message": fmt.Sprintf("Image is too large. Width and height may be at most %d pixels", maxImageSide),
+			})
+		}
+		if config.Width*config.Height > maxImagePixels {
+			return c.Status(400).JSON(fiber.Map{
+				"message": fmt.Sprintf("Image is too large. It may have at most %d megapixels", maxImagePixels/1000000),
+			})
+		}
+
+		img, _, err := image.Decode(bytes.NewReader(raw))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"message": "File is not a valid image",
+			})
+		}
+
+		key := prefix + "/original" + extension
+		url, err := storage.Media.Save(key, bytes.NewReader(raw))
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{
+				"message": "Failed to store file",
+				"error":   err.Error(),
+			})
+		}
+		savedKeys = append(savedKeys, key)
+		assets = append(assets, models.MediaAsset{
+			MovieID:     movie.ID,
+			Kind:        kind,
+			Variant:     "original",
+			StorageKey:  key,
+			URL:         url,
+			ContentType: contentType,
+			Size:        int64(len(raw)),
+			Width:       img.Bounds().Dx(),
+			Height:      img.Bounds().Dy(),
+		})
+
+		for variant, width := range rule.Variants {
+			resized := storage.Resize(img, width)
+
+			var buf bytes.Buffer
+			if err := jpeg.Encode(&buf, resized, &jpeg.Options{Quality: 85}); err != nil {
+				cleanup()
+				return c.Status(500).JSON(fiber.Map{
+					"message": "Failed to resize image",
+					"error":   err.Error(),
+				})
+			}
+
+			size := int64(buf.Len())
+			key := prefix + "/" + variant + ".jpg"
+			url, err := storage.Media.Save(key, &buf)
+			if err != nil {
+				cleanup()
+				return c.Status(500).JSON(fiber.Map{
+					"message": "Failed to store file",
+					"error":   err.Error(),
+				})
+			}
+			savedKeys = append(savedKeys, key)
+			assets = append(assets, models.MediaAsset{
+				MovieID:     movie.ID,
+				Kind:        kind,
+				Variant:     variant,
+				StorageKey:  key,
+				URL:         url,
+				ContentType: "image/jpeg",
+				Size:        size,
+				Width:       resized.Bounds().Dx(),
+				Height:      resized.Bounds().Dy(),
+			})
+		}
+	}
+
+	var previous []models.MediaAsset
+	database.DB.Where("movie_id = ? AND kind = ?", movie.ID, kind).Find(&previous)
+
+	before := movie
+	switch kind {
+	case "poster":
+		movie.PosterURL = assets[0].URL
+	case "backdrop":
+		movie.BackdropURL = assets[0].URL
+	case "trailer":
+		movie.TrailerURL = assets[0].URL
+	}
+
+	tx := database.DB.Begin()
+
+	if err := tx.Where("movie_id = ? AND kind = ?", movie.ID, kind).Delete(&models.MediaAsset{}).Error; err != nil {
+		tx.Rollback()
+		cleanup()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to save media",
+			"error":   err.Error(),
+		})
+	}
+
+	if err := tx.Create(&assets).Error; err != nil {
+		tx.Rollback()
+		cleanup()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to save media",
+			"error":   err.Error(),
+		})
+	}
+
+	if err := tx.Model(&movie).Update(kind+"_url", assets[0].URL).Error; err != nil {
+		tx.Rollback()
+		cleanup()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to save media",
+			"error":   err.Error(),
+		})
+	}
+
+	if err := recordAudit(tx, c, "update", "movie", movie.ID, before, movie); err != nil {
+		tx.Rollback()
+		cleanup()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to save media",
+			"error":   err.Error(),
+		})
+	}
+
+	tx.Commit()
+
+	// Files of the replaced upload are only removed once the new one is saved
+	for _, asset := range previous {
+		storage.Media.Delete(asset.StorageKey)
+	}
+
+	return c.Status(201).JSON(fiber.Map{
+		"message": "Media uploaded successfully",
+		"media":   assets,
+	})
+}
+
+// GetMoviePoster redirects to the poster variant closest to ?size=
+// (small, medium, large or original), falling back to larger sizes
+func GetMoviePoster(c *fiber.Ctx) error {
+	id := c.Params("id")
+	size := c.Query("size", "medium")
+
+	order := []string{"small", "medium", "large", "original"}
+	start := -1
+	for i, variant := range order {
+		if variant == size {
+			start = i
+		}
+	}
+	if start < 0 {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "size must be small, medium, large or original",
+		})
+	}
+
+	var assets []models.MediaAsset
+	database.DB.Scopes(movieDataInChain(c)).Where("movie_id = ? AND kind = ?", id, "poster").Find(&assets)
+
+	urls := map[string]string{}
+	for _, asset := range assets {
+		urls[asset.Variant] = asset.URL
+	}
+
+	for _, variant := range order[start:] {
+		if url, ok := urls[variant]; ok {
+			return c.Redirect(url)
+		}
+	}
+
+	// Posters entered as a plain URL before uploads existed
+	var movie models.Movie
+	if err := database.DB.Scopes(inChain(c)).First(&movie, id).Error; err == nil && movie.PosterURL != "" {
+		return c.Redirect(movie.PosterURL)
+	}
+
+	return c.Status(404).JSON(fiber.Map{
+		"message": "Poster not found",
+	})
+}
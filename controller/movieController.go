@@ -6,6 +6,8 @@ import (
 	"github.com/SaharKhamseh/cinema-backend/database"
 	"github.com/SaharKhamseh/cinema-backend/models"
 	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // CreateMovie creates a new movie
@@ -36,41 +38,98 @@ func CreateMovie(c *fiber.Ctx) error {
 		})
 	}
 
+	// genre is optional when genre_ids are given
+	genre, _ := data["genre"].(string)
+
 	movie := models.Movie{
+		ChainID:     chainID(c),
 		Title:       data["title"].(string),
 		Description: data["description"].(string),
 		Duration:    int(data["duration"].(float64)),
-		Genre:       data["genre"].(string),
+		Genre:       genre,
 		Language:    data["language"].(string),
 		ReleaseDate: releaseDate,
 		PosterURL:   data["poster_url"].(string),
 	}
 
-	if err := database.DB.Create(&movie).Error; err != nil {
+	tx := database.DB.Begin()
+
+	if err := tx.Create(&movie).Error; err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to create movie",
+			"error":   err.Error(),
+		})
+	}
+
+	if err := setMovieTaxonomy(tx, &movie, data); err != nil {
+		tx.Rollback()
+		return c.Status(400).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	if err := recordAudit(tx, c, "create", "movie", movie.ID, nil, movie); err != nil {
+		tx.Rollback()
 		return c.Status(500).JSON(fiber.Map{
 			"message": "Failed to create movie",
 			"error":   err.Error(),
 		})
 	}
 
+	tx.Commit()
+
 	return c.Status(201).JSON(fiber.Map{
 		"message": "Movie created successfully",
 		"movie":   movie,
 	})
 }
 
-// GetMovies returns all movies
+// GetMovies returns a page of the movie catalogue filtered and sorted by
+// the query parameters, along with genre and language facet counts
 func GetMovies(c *fiber.Ctx) error {
+	filter, err := parseMovieFilter(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
 	var movies []models.Movie
+	meta, err := paginateOffset(c, database.DB.Scopes(inChain(c), filter.scope), &movies, movieSortFields, "title ASC", preload("Genres", "Tags"))
+	if err != nil {
+		return pageErrorResponse(c, err, "Failed to fetch movies")
+	}
 
-	if err := database.DB.Find(&movies).Error; err != nil {
+	genres, err := genreFacets(database.DB.Scopes(inChain(c), filter.scope))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to count genres",
+			"error":   err.Error(),
+		})
+	}
+
+	languages, err := movieFacets(database.DB.Scopes(inChain(c), filter.scope), "language")
+	if err != nil {
 		return c.Status(500).JSON(fiber.Map{
-			"message": "Failed to fetch movies",
+			"message": "Failed to count languages",
 			"error":   err.Error(),
 		})
 	}
 
-	return c.JSON(movies)
+	pointers := make([]*models.Movie, len(movies))
+	for i := range movies {
+		pointers[i] = &movies[i]
+	}
+	localizeMovies(c, pointers...)
+
+	response := pageEnvelope(movies, meta)
+	response["facets"] = fiber.Map{
+		"genre":    genres,
+		"language": languages,
+	}
+
+	return c.JSON(response)
 }
 
 // GetMovie returns a specific movie
@@ -78,12 +137,22 @@ func GetMovie(c *fiber.Ctx) error {
 	id := c.Params("id")
 	var movie models.Movie
 
-	if err := database.DB.First(&movie, id).Error; err != nil {
+	if err := database.DB.
+		Scopes(inChain(c)).
+		Preload("Genres").
+		Preload("Tags").
+		Preload("Credits", func(db *gorm.DB) *gorm.DB {
+			return db.Order("billing_order, id")
+		}).
+		Preload("Credits.Person").
+		First(&movie, id).Error; err != nil {
 		return c.Status(404).JSON(fiber.Map{
 			"message": "Movie not found",
 		})
 	}
 
+	localizeMovies(c, &movie)
+
 	return c.JSON(movie)
 }
 
@@ -92,7 +161,7 @@ func UpdateMovie(c *fiber.Ctx) error {
 	id := c.Params("id")
 	var movie models.Movie
 
-	if err := database.DB.First(&movie, id).Error; err != nil {
+	if err := database.DB.Scopes(inChain(c)).Preload("Genres").Preload("Tags").First(&movie, id).Error; err != nil {
 		return c.Status(404).JSON(fiber.Map{
 			"message": "Movie not found",
 		})
@@ -105,6 +174,8 @@ func UpdateMovie(c *fiber.Ctx) error {
 		})
 	}
 
+	before := movie
+
 	// Update fields if they exist in the request
 	if data["title"] != nil {
 		movie.Title = data["title"].(string)
@@ -131,7 +202,32 @@ func UpdateMovie(c *fiber.Ctx) error {
 		movie.PosterURL = data["poster_url"].(string)
 	}
 
-	database.DB.Save(&movie)
+	tx := database.DB.Begin()
+
+	if err := tx.Omit(clause.Associations).Save(&movie).Error; err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to update movie",
+			"error":   err.Error(),
+		})
+	}
+
+	if err := setMovieTaxonomy(tx, &movie, data); err != nil {
+		tx.Rollback()
+		return c.Status(400).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	if err := recordAudit(tx, c, "update", "movie", movie.ID, before, movie); err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to update movie",
+			"error":   err.Error(),
+		})
+	}
+
+	tx.Commit()
 
 	return c.JSON(fiber.Map{
 		"message": "Movie updated successfully",
@@ -139,20 +235,62 @@ func UpdateMovie(c *fiber.Ctx) error {
 	})
 }
 
-// DeleteMovie deletes a movie
+// DeleteMovie soft-deletes a movie. It is refused while the movie has
+// future show times or active bookings unless ?cascade=true is given, in
+// which case those shows are removed and their bookings cancelled.
 func DeleteMovie(c *fiber.Ctx) error {
 	id := c.Params("id")
 	var movie models.Movie
 
-	if err := database.DB.First(&movie, id).Error; err != nil {
+	if err := database.DB.Scopes(inChain(c)).First(&movie, id).Error; err != nil {
 		return c.Status(404).JSON(fiber.Map{
 			"message": "Movie not found",
 		})
 	}
 
-	database.DB.Delete(&movie)
+	impact, err := futureShowTimeImpact("movie_id = ?", movie.ID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to delete movie",
+			"error":   err.Error(),
+		})
+	}
+
+	cascade := c.QueryBool("cascade")
+	if impact.blocking() && !cascade {
+		return blockedDeletion(c, impact)
+	}
+
+	tx := database.DB.Begin()
+
+	if err := cascadeShowTimes(tx, c, impact.FutureShowTimes); err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to delete movie",
+			"error":   err.Error(),
+		})
+	}
+
+	if err := tx.Delete(&movie).Error; err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to delete movie",
+			"error":   err.Error(),
+		})
+	}
+
+	if err := recordAudit(tx, c, "delete", "movie", movie.ID, movie, nil); err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to delete movie",
+			"error":   err.Error(),
+		})
+	}
+
+	tx.Commit()
 
 	return c.JSON(fiber.Map{
 		"message": "Movie deleted successfully",
+		"impact":  impact,
 	})
 }
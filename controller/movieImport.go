@@ -0,0 +1,310 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SaharKhamseh/cinema-backend/database"
+	"github.com/SaharKhamseh/cinema-backend/models"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ImportRowResult is the outcome of importing one row
+type ImportRowResult struct {
+	Row     int      `json:"row"`
+	Status  string   `json:"status"` // created, updated, failed
+	MovieID uint     `json:"movie_id,omitempty"`
+	Title   string   `json:"title"`
+	Errors  []string `json:"errors,omitempty"`
+}
+
+// ImportReport summarises a catalogue import
+type ImportReport struct {
+	DryRun  bool              `json:"dry_run"`
+	Created int               `json:"created"`
+	Updated int               `json:"updated"`
+	Failed  int               `json:"failed"`
+	Rows    []ImportRowResult `json:"rows"`
+}
+
+// ParseMovieFile reads movie rows from a CSV file with a header line or
+// from a JSON array of objects. Columns are external_id, title,
+// description, duration, genres, language, release_date and poster_url;
+// genres are separated by "|" in CSV and may be an array in JSON.
+func ParseMovieFile(r io.Reader, format string) ([]map[string]string, error) {
+	switch format {
+	case "csv":
+		reader := csv.NewReader(r)
+		reader.TrimLeadingSpace = true
+
+		records, err := reader.ReadAll()
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV: %w", err)
+		}
+		if len(records) == 0 {
+			return nil, fmt.Errorf("CSV file is empty")
+		}
+
+		header := records[0]
+		for i := range header {
+			header[i] = strings.ToLower(strings.TrimSpace(header[i]))
+		}
+
+		rows := make([]map[string]string, 0, len(records)-1)
+		for _, record := range records[1:] {
+			row := map[string]string{}
+			for i, value := range record {
+				if i < len(header) {
+					row[header[i]] = strings.TrimSpace(value)
+				}
+			}
+			rows = append(rows, row)
+		}
+		return rows, nil
+
+	case "json":
+		decoder := json.NewDecoder(r)
+		decoder.UseNumber()
+
+		var items []map[string]interface{}
+		if err := decoder.Decode(&items); err != nil {
+			return nil, fmt.Errorf("invalid JSON: %w", err)
+		}
+
+		rows := make([]map[string]string, 0, len(items))
+		for _, item := range items {
+			row := map[string]string{}
+			for key, value := range item {
+				switch v := value.(type) {
+				case nil:
+				case []interface{}:
+					parts := make([]string, len(v))
+					for i, part := range v {
+						parts[i] = fmt.Sprint(part)
+					}
+					row[strings.ToLower(key)] = strings.Join(parts, "|")
+				default:
+					row[strings.ToLower(key)] = strings.TrimSpace(fmt.Sprint(v))
+				}
+			}
+			rows = append(rows, row)
+		}
+		return rows, nil
+	}
+
+	return nil, fmt.Errorf("unsupported format %q. Use csv or json", format)
+}
+
+// ImportMovies validates and upserts each row. A row matches an existing
+// movie by external_id when given, otherwise by title and release date.
+// Every row runs in its own savepoint so one bad row does not undo the
+// others; with dryRun the whole transaction is rolled back at the end.
+// Movies are matched and created within the given chain.
+func ImportMovies(rows []map[string]string, dryRun bool, chainID, actorID uint) (ImportReport, error) {
+	report := ImportReport{DryRun: dryRun, Rows: []ImportRowResult{}}
+
+	tx := database.DB.Begin()
+	if tx.Error != nil {
+		return report, tx.Error
+	}
+
+	for i, row := range rows {
+		result := ImportRowResult{Row: i + 1, Title: row["title"]}
+		savepoint := fmt.Sprintf("import_row_%d", i+1)
+
+		tx.SavePoint(savepoint)
+		movieID, created, errs := importMovieRow(tx, row, chainID, actorID)
+		if len(errs) > 0 {
+			tx.RollbackTo(savepoint)
+			result.Status = "failed"
+			result.Errors = errs
+			report.Failed++
+		} else if created {
+			result.Status = "created"
+			result.MovieID = movieID
+			report.Created++
+		} else {
+			result.Status = "updated"
+			result.MovieID = movieID
+			report.Updated++
+		}
+
+		report.Rows = append(report.Rows, result)
+	}
+
+	if dryRun {
+		tx.Rollback()
+		return report, nil
+	}
+
+	return report, tx.Commit().Error
+}
+
+// importMovieRow applies a single row and returns the movie id, whether it
+// was created, and the validation or database errors for the row
+func importMovieRow(tx *gorm.DB, row map[string]string, chainID, actorID uint) (uint, bool, []string) {
+	var errs []string
+
+	movie := models.Movie{ChainID: chainID}
+	found := false
+
+	externalID := row["external_id"]
+	if externalID != "" {
+		if err := tx.Unscoped().Where("chain_id = ? AND external_id = ?", chainID, externalID).First(&movie).Error; err == nil {
+			if movie.DeletedAt.Valid {
+				return 0, false, []string{"a deleted movie uses this external_id, restore it first"}
+			}
+			found = true
+		}
+	}
+
+	var releaseDate time.Time
+	if v := row["release_date"]; v != "" {
+		t, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			errs = append(errs, "release_date must use YYYY-MM-DD")
+		}
+		releaseDate = t
+	}
+
+	if !found && externalID == "" && row["title"] != "" && !releaseDate.IsZero() {
+		if err := tx.
+			Where("chain_id = ?", chainID).
+			Where("LOWER(title) = ? AND release_date >= ? AND release_date < ?",
+				strings.ToLower(row["title"]), releaseDate, releaseDate.Add(24*time.Hour)).
+			First(&movie).Error; err == nil {
+			found = true
+		}
+	}
+
+	before := movie
+
+	// New movies need every required field; updates only touch the
+	// columns present in the row
+	if !found {
+		for _, field := range []string{"title", "duration", "language", "release_date"} {
+			if row[field] == "" {
+				errs = append(errs, field+" is required")
+			}
+		}
+	}
+
+	if v := row["title"]; v != "" {
+		movie.Title = v
+	}
+	if v := row["description"]; v != "" {
+		movie.Description = v
+	}
+	if v := row["duration"]; v != "" {
+		duration, err := strconv.Atoi(v)
+		if err != nil || duration <= 0 {
+			errs = append(errs, "duration must be a positive number of minutes")
+		}
+		movie.Duration = duration
+	}
+	if v := row["language"]; v != "" {
+		movie.Language = v
+	}
+	if !releaseDate.IsZero() {
+		movie.ReleaseDate = releaseDate
+	}
+	if v := row["poster_url"]; v != "" {
+		movie.PosterURL = v
+	}
+	if externalID != "" {
+		movie.ExternalID = &externalID
+	}
+
+	if len(errs) > 0 {
+		return 0, false, errs
+	}
+
+	if err := tx.Omit(clause.Associations).Save(&movie).Error; err != nil {
+		return 0, false, []string{err.Error()}
+	}
+
+	if v := row["genres"]; v != "" {
+		if err := importGenres(tx, &movie, v); err != nil {
+			return 0, false, []string{err.Error()}
+		}
+	}
+
+	action := "update"
+	var auditBefore interface{} = before
+	if !found {
+		action = "create"
+		auditBefore = nil
+	}
+	if err := writeAudit(tx, chainID, actorID, action, "movie", movie.ID, auditBefore, movie); err != nil {
+		return 0, false, []string{err.Error()}
+	}
+
+	return movie.ID, !found, nil
+}
+
+// importGenres links the "|" separated genre names to the movie, creating
+// missing genres of the movie's chain by slug
+func importGenres(tx *gorm.DB, movie *models.Movie, value string) error {
+	genres, err := resolveGenres(tx, movie.ChainID, value)
+	if err != nil {
+		return err
+	}
+	return linkGenres(tx, movie, genres)
+}
+
+// ImportMovieCatalogue imports movies from an uploaded CSV or JSON file.
+// The file goes in the "file" form field, or as the raw body with ?format=.
+// Pass ?dry_run=true to validate without saving.
+func ImportMovieCatalogue(c *fiber.Ctx) error {
+	format := strings.ToLower(c.Query("format"))
+	var content io.Reader
+
+	if fileHeader, err := c.FormFile("file"); err == nil {
+		file, err := fileHeader.Open()
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"message": "Unable to read uploaded file",
+			})
+		}
+		defer file.Close()
+
+		content = file
+		if format == "" {
+			format = strings.TrimPrefix(strings.ToLower(filepath.Ext(fileHeader.Filename)), ".")
+		}
+	} else {
+		content = bytes.NewReader(c.Body())
+		if format == "" && strings.Contains(c.Get(fiber.HeaderContentType), "json") {
+			format = "json"
+		} else if format == "" {
+			format = "csv"
+		}
+	}
+
+	rows, err := ParseMovieFile(content, format)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	actorID, _ := currentUserID(c)
+	report, err := ImportMovies(rows, c.QueryBool("dry_run"), chainID(c), actorID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to import movies",
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(report)
+}
@@ -0,0 +1,67 @@
+package controller
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseMovieFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		content string
+		want    []map[string]string
+		wantErr string
+	}{
+		{
+			name:    "csv with header",
+			format:  "csv",
+			content: "External_ID, Title ,genres\nm-1, Arrival ,Drama|Sci-Fi\nm-2,Heat,\n",
+			want: []map[string]string{
+				{"external_id": "m-1", "title": "Arrival", "genres": "Drama|Sci-Fi"},
+				{"external_id": "m-2", "title": "Heat", "genres": ""},
+			},
+		},
+		{
+			name:    "json with genre array and numbers",
+			format:  "json",
+			content: `[{"Title": " Arrival ", "duration": 116, "genres": ["Drama", "Sci-Fi"], "poster_url": null}]`,
+			want: []map[string]string{
+				{"title": "Arrival", "duration": "116", "genres": "Drama|Sci-Fi"},
+			},
+		},
+		{name: "empty csv", format: "csv", content: "", wantErr: "CSV file is empty"},
+		{name: "ragged csv", format: "csv", content: "title,genres\nArrival\n", wantErr: "invalid CSV"},
+		{name: "json object", format: "json", content: `{"title": "Arrival"}`, wantErr: "invalid JSON"},
+		{name: "unknown format", format: "xml", content: "<movies/>", wantErr: "unsupported format"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := ParseMovieFile(strings.NewReader(tt.content), tt.format)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseMovieFile failed: %v", err)
+			}
+			if len(rows) != len(tt.want) {
+				t.Fatalf("got %d rows, want %d", len(rows), len(tt.want))
+			}
+			for i, row := range rows {
+				if len(row) != len(tt.want[i]) {
+					t.Errorf("row %d = %v, want %v", i, row, tt.want[i])
+					continue
+				}
+				for key, value := range tt.want[i] {
+					if row[key] != value {
+						t.Errorf("row %d %s = %q, want %q", i, key, row[key], value)
+					}
+				}
+			}
+		})
+	}
+}
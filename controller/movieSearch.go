@@ -0,0 +1,193 @@
+package controller
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/SaharKhamseh/cinema-backend/models"
+	"github.com/SaharKhamseh/cinema-backend/util"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// FacetCount is the number of movies sharing one facet value
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+// movieSortFields whitelists the columns GetMovies may sort on
+var movieSortFields = map[string]string{
+	"title":        "title",
+	"release_date": "release_date",
+	"duration":     "duration",
+	"created_at":   "created_at",
+}
+
+// movieFilter holds the catalogue query parameters once validated
+type movieFilter struct {
+	Query        string
+	Genres       []string // genre slugs
+	GenreMatch   string   // any, all
+	Tags         []string // tag slugs
+	TagMatch     string   // any, all
+	Languages    []string
+	ReleasedFrom time.Time
+	ReleasedTo   time.Time
+	Status       string // now_showing, coming_soon
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseMovieFilter reads q, genre, genre_match, tag, tag_match, language,
+// released_from, released_to and status from the query string. genre, tag
+// and language accept comma separated lists; genres and tags are matched by
+// slug and by default a movie needs any one of them.
+func parseMovieFilter(c *fiber.Ctx) (movieFilter, error) {
+	filter := movieFilter{
+		Query:      strings.TrimSpace(c.Query("q")),
+		GenreMatch: c.Query("genre_match", "any"),
+		TagMatch:   c.Query("tag_match", "any"),
+		Languages:  splitList(c.Query("language")),
+		Status:     c.Query("status"),
+	}
+
+	for _, genre := range splitList(c.Query("genre")) {
+		filter.Genres = append(filter.Genres, util.Slugify(genre))
+	}
+	for _, tag := range splitList(c.Query("tag")) {
+		filter.Tags = append(filter.Tags, util.Slugify(tag))
+	}
+
+	if filter.GenreMatch != "any" && filter.GenreMatch != "all" {
+		return filter, fmt.Errorf("genre_match must be any or all")
+	}
+	if filter.TagMatch != "any" && filter.TagMatch != "all" {
+		return filter, fmt.Errorf("tag_match must be any or all")
+	}
+
+	if v := c.Query("released_from"); v != "" {
+		t, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			return filter, fmt.Errorf("invalid released_from date format. Use YYYY-MM-DD")
+		}
+		filter.ReleasedFrom = t
+	}
+	if v := c.Query("released_to"); v != "" {
+		t, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			return filter, fmt.Errorf("invalid released_to date format. Use YYYY-MM-DD")
+		}
+		filter.ReleasedTo = t.Add(24 * time.Hour)
+	}
+
+	if filter.Status != "" && filter.Status != "now_showing" && filter.Status != "coming_soon" {
+		return filter, fmt.Errorf("status must be now_showing or coming_soon")
+	}
+
+	return filter, nil
+}
+
+// scope applies the filter to a movies query. A movie is now showing when
+// it has been released and has a show that has not started yet; it is
+// coming soon while its release date is in the future.
+func (f movieFilter) scope(query *gorm.DB) *gorm.DB {
+	if f.Query != "" {
+		like := "%" + strings.ToLower(f.Query) + "%"
+		query = query.Where("LOWER(movies.title) LIKE ? OR LOWER(movies.description) LIKE ?", like, like)
+	}
+	if len(f.Genres) > 0 {
+		query = matchTaxonomy(query, "movie_genres", "genres", "genre_id", f.Genres, f.GenreMatch)
+	}
+	if len(f.Tags) > 0 {
+		query = matchTaxonomy(query, "movie_tags", "tags", "tag_id", f.Tags, f.TagMatch)
+	}
+	if len(f.Languages) > 0 {
+		query = query.Where("movies.language IN ?", f.Languages)
+	}
+	if !f.ReleasedFrom.IsZero() {
+		query = query.Where("movies.release_date >= ?", f.ReleasedFrom)
+	}
+	if !f.ReleasedTo.IsZero() {
+		query = query.Where("movies.release_date < ?", f.ReleasedTo)
+	}
+
+	now := time.Now()
+	switch f.Status {
+	case "now_showing":
+		query = query.
+			Where("movies.release_date <= ?", now).
+			Where("EXISTS (SELECT 1 FROM show_times WHERE show_times.movie_id = movies.id AND show_times.start_time >= ? AND show_times.deleted_at IS NULL)", now)
+	case "coming_soon":
+		query = query.Where("movies.release_date > ?", now)
+	}
+
+	return query
+}
+
+// matchTaxonomy keeps movies linked to any or all of the given slugs
+// through a many2many join table
+func matchTaxonomy(query *gorm.DB, joinTable, table, foreignKey string, slugs []string, match string) *gorm.DB {
+	linked := fmt.Sprintf(
+		"SELECT COUNT(DISTINCT %[2]s.slug) FROM %[1]s JOIN %[2]s ON %[2]s.id = %[1]s.%[3]s WHERE %[1]s.movie_id = movies.id AND %[2]s.slug IN ?",
+		joinTable, table, foreignKey,
+	)
+
+	if match == "all" {
+		return query.Where("("+linked+") = ?", slugs, len(slugs))
+	}
+	return query.Where("("+linked+") > 0", slugs)
+}
+
+// parseSort turns "field" or "-field" into an ORDER BY clause using the whitelist
+func parseSort(value string, fields map[string]string, fallback string) (string, error) {
+	if value == "" {
+		return fallback, nil
+	}
+
+	direction := "ASC"
+	if strings.HasPrefix(value, "-") {
+		direction = "DESC"
+		value = value[1:]
+	}
+
+	column, ok := fields[value]
+	if !ok {
+		return "", fmt.Errorf("cannot sort by %s", value)
+	}
+
+	return column + " " + direction, nil
+}
+
+// genreFacets counts the filtered movies per linked genre
+func genreFacets(query *gorm.DB) ([]FacetCount, error) {
+	facets := []FacetCount{}
+	err := query.Model(&models.Movie{}).
+		Select("genres.name AS value, COUNT(DISTINCT movies.id) AS count").
+		Joins("JOIN movie_genres ON movie_genres.movie_id = movies.id").
+		Joins("JOIN genres ON genres.id = movie_genres.genre_id").
+		Group("genres.name").
+		Order("count DESC").
+		Scan(&facets).Error
+	return facets, err
+}
+
+// movieFacets counts the filtered movies per value of a column
+func movieFacets(query *gorm.DB, column string) ([]FacetCount, error) {
+	facets := []FacetCount{}
+	err := query.Model(&models.Movie{}).
+		Select("movies." + column + " AS value, COUNT(*) AS count").
+		Group("movies." + column).
+		Order("count DESC").
+		Scan(&facets).Error
+	return facets, err
+}
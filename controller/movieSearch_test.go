@@ -0,0 +1,48 @@
+package controller
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSplitList(t *testing.T) {
+	tests := []struct {
+		value string
+		want  []string
+	}{
+		{"", nil},
+		{"action", []string{"action"}},
+		{" action , sci-fi,,drama ", []string{"action", "sci-fi", "drama"}},
+		{",,", nil},
+	}
+
+	for _, tt := range tests {
+		if got := splitList(tt.value); !slices.Equal(got, tt.want) {
+			t.Errorf("splitList(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestParseSort(t *testing.T) {
+	fields := map[string]string{"title": "title", "rating": "average_rating"}
+
+	tests := []struct {
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{"", "title ASC", false},
+		{"title", "title ASC", false},
+		{"-rating", "average_rating DESC", false},
+		{"password", "", true},
+		{"-", "", true},
+		{"title; DROP TABLE movies", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseSort(tt.value, fields, "title ASC")
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseSort(%q) = %q, %v; want %q, error %v", tt.value, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,253 @@
+package controller
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/SaharKhamseh/cinema-backend/database"
+	"github.com/SaharKhamseh/cinema-backend/models"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ShowTimeOccupancy describes how full a single show is
+type ShowTimeOccupancy struct {
+	ShowTimeID uint      `json:"show_time_id"`
+	MovieID    uint      `json:"movie_id"`
+	MovieTitle string    `json:"movie_title"`
+	ScreenID   uint      `json:"screen_id"`
+	StartTime  time.Time `json:"start_time"`
+	Seats      int       `json:"seats"`
+	Sold       int       `json:"sold"`
+	Held       int       `json:"held"`
+	Available  int       `json:"available"`
+	LoadFactor float64   `json:"load_factor"`
+}
+
+// OccupancyGroup is the aggregate of several shows sharing a bucket
+type OccupancyGroup struct {
+	Key        string  `json:"key"`
+	Shows      int     `json:"shows"`
+	Seats      int     `json:"seats"`
+	Sold       int     `json:"sold"`
+	Held       int     `json:"held"`
+	Available  int     `json:"available"`
+	LoadFactor float64 `json:"load_factor"`
+}
+
+// loadOccupancy computes sold, held and available seats for every show
+// starting inside the given range. Seats of bookings that are not cancelled
+// count as sold, and unsold seats kept off sale by seat blocks as held.
+// Only shows of the request's chain are counted.
+func loadOccupancy(c *fiber.Ctx, from, to time.Time) ([]ShowTimeOccupancy, error) {
+	query := database.DB.Scopes(showTimesInChain(c)).Preload("Movie").Order("start_time")
+	if !from.IsZero() {
+		query = query.Where("start_time >= ?", from)
+	}
+	if !to.IsZero() {
+		query = query.Where("start_time < ?", to)
+	}
+
+	var showTimes []models.ShowTime
+	if err := query.Find(&showTimes).Error; err != nil {
+		return nil, err
+	}
+	if len(showTimes) == 0 {
+		return []ShowTimeOccupancy{}, nil
+	}
+
+	var seatCounts []struct {
+		ScreenID uint
+		Total    int
+	}
+	if err := database.DB.Model(&models.Seat{}).
+		Select("screen_id, COUNT(*) AS total").
+		Group("screen_id").
+		Scan(&seatCounts).Error; err != nil {
+		return nil, err
+	}
+	seatsPerScreen := map[uint]int{}
+	for _, row := range seatCounts {
+		seatsPerScreen[row.ScreenID] = row.Total
+	}
+
+	showTimeIDs := make([]uint, len(showTimes))
+	screenIDs := make([]uint, len(showTimes))
+	for i, showTime := range showTimes {
+		showTimeIDs[i] = showTime.ID
+		screenIDs[i] = showTime.ScreenID
+	}
+
+	var bookedSeats []struct {
+		ShowTimeID uint
+		SeatID     uint
+	}
+	if err := database.DB.Model(&models.Booking{}).
+		Select("bookings.show_time_id, booking_seats.seat_id").
+		Joins("JOIN booking_seats ON bookings.id = booking_seats.booking_id").
+		Where("bookings.show_time_id IN ? AND bookings.status != ?", showTimeIDs, "cancelled").
+		Scan(&bookedSeats).Error; err != nil {
+		return nil, err
+	}
+	booked := map[uint]map[uint]bool{}
+	for _, row := range bookedSeats {
+		if booked[row.ShowTimeID] == nil {
+			booked[row.ShowTimeID] = map[uint]bool{}
+		}
+		booked[row.ShowTimeID][row.SeatID] = true
+	}
+
+	var blocks []models.SeatBlock
+	if err := database.DB.Preload("Seats").
+		Where("screen_id IN ? AND released_at IS NULL", screenIDs).
+		Find(&blocks).Error; err != nil {
+		return nil, err
+	}
+
+	sold := map[uint]int{}
+	held := map[uint]int{}
+	for _, showTime := range showTimes {
+		sold[showTime.ID] = len(booked[showTime.ID])
+
+		heldSeats := map[uint]bool{}
+		for _, block := range blocks {
+			if !blockApplies(block, showTime) {
+				continue
+			}
+			for _, seat := range block.Seats {
+				if !booked[showTime.ID][seat.ID] {
+					heldSeats[seat.ID] = true
+				}
+			}
+		}
+		held[showTime.ID] = len(heldSeats)
+	}
+
+	result := make([]ShowTimeOccupancy, 0, len(showTimes))
+	for _, showTime := range showTimes {
+		occupancy := ShowTimeOccupancy{
+			ShowTimeID: showTime.ID,
+			MovieID:    showTime.MovieID,
+			MovieTitle: showTime.Movie.Title,
+			ScreenID:   showTime.ScreenID,
+			StartTime:  showTime.StartTime,
+			Seats:      seatsPerScreen[showTime.ScreenID],
+			Sold:       sold[showTime.ID],
+			Held:       held[showTime.ID],
+		}
+		occupancy.Available = occupancy.Seats - occupancy.Sold - occupancy.Held
+		if occupancy.Available < 0 {
+			occupancy.Available = 0
+		}
+		if occupancy.Seats > 0 {
+			occupancy.LoadFactor = float64(occupancy.Sold) / float64(occupancy.Seats)
+		}
+		result = append(result, occupancy)
+	}
+
+	return result, nil
+}
+
+// occupancyKey returns the bucket a show falls into for a dimension.
+// Movie weeks are counted from the release date, starting at week 1.
+func occupancyKey(occupancy ShowTimeOccupancy, releaseDate time.Time, dimension string) string {
+	switch dimension {
+	case "slot":
+		return occupancy.StartTime.Format("15:00")
+	case "weekday":
+		return fmt.Sprintf("%d-%s", int(occupancy.StartTime.Weekday()), occupancy.StartTime.Weekday())
+	case "movie_week":
+		week := 1
+		if !releaseDate.IsZero() && occupancy.StartTime.After(releaseDate) {
+			week = int(occupancy.StartTime.Sub(releaseDate).Hours()/24)/7 + 1
+		}
+		return fmt.Sprintf("%d/week-%02d", occupancy.MovieID, week)
+	}
+	return ""
+}
+
+// GetShowTimeOccupancy returns the load factor of each show in a date range
+func GetShowTimeOccupancy(c *fiber.Ctx) error {
+	from, to, err := parseDateRange(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	occupancy, err := loadOccupancy(c, from, to)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to compute occupancy",
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(occupancy)
+}
+
+// GetOccupancyReport aggregates show occupancy by time slot, weekday or movie week
+func GetOccupancyReport(c *fiber.Ctx) error {
+	dimension := c.Params("dimension")
+	if dimension != "slot" && dimension != "weekday" && dimension != "movie_week" {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Unknown dimension. Use slot, weekday or movie_week",
+		})
+	}
+
+	from, to, err := parseDateRange(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	occupancy, err := loadOccupancy(c, from, to)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to compute occupancy",
+			"error":   err.Error(),
+		})
+	}
+
+	releaseDates := map[uint]time.Time{}
+	if dimension == "movie_week" {
+		var movies []models.Movie
+		database.DB.Scopes(inChain(c)).Select("id, release_date").Find(&movies)
+		for _, movie := range movies {
+			releaseDates[movie.ID] = movie.ReleaseDate
+		}
+	}
+
+	index := map[string]*OccupancyGroup{}
+	var keys []string
+	for _, show := range occupancy {
+		key := occupancyKey(show, releaseDates[show.MovieID], dimension)
+		group, ok := index[key]
+		if !ok {
+			group = &OccupancyGroup{Key: key}
+			index[key] = group
+			keys = append(keys, key)
+		}
+		group.Shows++
+		group.Seats += show.Seats
+		group.Sold += show.Sold
+		group.Held += show.Held
+		group.Available += show.Available
+	}
+
+	sort.Strings(keys)
+	groups := make([]OccupancyGroup, 0, len(keys))
+	for _, key := range keys {
+		group := index[key]
+		if group.Seats > 0 {
+			group.LoadFactor = float64(group.Sold) / float64(group.Seats)
+		}
+		groups = append(groups, *group)
+	}
+
+	return c.JSON(fiber.Map{
+		"dimension": dimension,
+		"groups":    groups,
+	})
+}
@@ -0,0 +1,37 @@
+package controller
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOccupancyKey(t *testing.T) {
+	release := time.Date(2025, 3, 7, 0, 0, 0, 0, time.UTC)
+	show := func(start time.Time) ShowTimeOccupancy {
+		return ShowTimeOccupancy{MovieID: 4, StartTime: start}
+	}
+
+	tests := []struct {
+		name      string
+		start     time.Time
+		release   time.Time
+		dimension string
+		want      string
+	}{
+		{"slot is the hour", time.Date(2025, 3, 8, 19, 45, 0, 0, time.UTC), release, "slot", "19:00"},
+		{"weekday", time.Date(2025, 3, 9, 12, 0, 0, 0, time.UTC), release, "weekday", "0-Sunday"},
+		{"release day is week 1", time.Date(2025, 3, 7, 20, 0, 0, 0, time.UTC), release, "movie_week", "4/week-01"},
+		{"day 7 is week 2", time.Date(2025, 3, 14, 20, 0, 0, 0, time.UTC), release, "movie_week", "4/week-02"},
+		{"before release is week 1", time.Date(2025, 3, 1, 20, 0, 0, 0, time.UTC), release, "movie_week", "4/week-01"},
+		{"unknown release is week 1", time.Date(2025, 5, 1, 20, 0, 0, 0, time.UTC), time.Time{}, "movie_week", "4/week-01"},
+		{"unknown dimension", time.Date(2025, 3, 8, 19, 0, 0, 0, time.UTC), release, "month", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := occupancyKey(show(tt.start), tt.release, tt.dimension); got != tt.want {
+				t.Errorf("occupancyKey = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,229 @@
+package controller
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
+// PageMeta is the pagination block of every list response
+type PageMeta struct {
+	Total      int64  `json:"total"`
+	Limit      int    `json:"limit"`
+	Offset     int    `json:"offset"`
+	Sort       string `json:"sort"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// cursorField is a column that keyset pagination may order by. Time
+// columns are carried in the cursor as RFC 3339, everything else as a number.
+type cursorField struct {
+	Column string
+	Time   bool
+}
+
+type pageCursor struct {
+	ID    uint   `json:"id"`
+	Value string `json:"value"`
+}
+
+// pageRequestError marks a bad sort or cursor parameter, as opposed to a
+// database failure
+type pageRequestError struct {
+	message string
+}
+
+func (e pageRequestError) Error() string {
+	return e.message
+}
+
+// pageErrorResponse answers 400 for bad paging parameters and 500 otherwise
+func pageErrorResponse(c *fiber.Ctx, err error, message string) error {
+	var requestErr pageRequestError
+	if errors.As(err, &requestErr) {
+		return c.Status(400).JSON(fiber.Map{
+			"message": requestErr.Error(),
+		})
+	}
+
+	return c.Status(500).JSON(fiber.Map{
+		"message": message,
+		"error":   err.Error(),
+	})
+}
+
+// pageEnvelope wraps list results in the standard response shape
+func pageEnvelope(data interface{}, meta PageMeta) fiber.Map {
+	return fiber.Map{
+		"data":       data,
+		"pagination": meta,
+	}
+}
+
+func pageLimit(c *fiber.Ctx) int {
+	limit := c.QueryInt("limit", defaultPageLimit)
+	if limit <= 0 {
+		return defaultPageLimit
+	}
+	if limit > maxPageLimit {
+		return maxPageLimit
+	}
+	return limit
+}
+
+// preload returns a scope that preloads the named associations. Preloads
+// are passed to the paginators as scopes so they stay off the count query.
+func preload(names ...string) func(*gorm.DB) *gorm.DB {
+	return func(query *gorm.DB) *gorm.DB {
+		for _, name := range names {
+			query = query.Preload(name)
+		}
+		return query
+	}
+}
+
+// paginateOffset loads one limit/offset page of query into dest, sorted by
+// the whitelisted ?sort= field. Used for catalogue data.
+func paginateOffset(c *fiber.Ctx, query *gorm.DB, dest interface{}, sortFields map[string]string, fallback string, preloads ...func(*gorm.DB) *gorm.DB) (PageMeta, error) {
+	meta := PageMeta{
+		Limit:  pageLimit(c),
+		Offset: c.QueryInt("offset", 0),
+		Sort:   c.Query("sort"),
+	}
+	if meta.Offset < 0 {
+		meta.Offset = 0
+	}
+
+	order, err := parseSort(meta.Sort, sortFields, fallback)
+	if err != nil {
+		return meta, pageRequestError{err.Error()}
+	}
+
+	query = query.Session(&gorm.Session{})
+	if err := query.Model(dest).Count(&meta.Total).Error; err != nil {
+		return meta, err
+	}
+
+	err = query.Scopes(preloads...).Order(order).Limit(meta.Limit).Offset(meta.Offset).Find(dest).Error
+	return meta, err
+}
+
+// paginateCursor loads one keyset page of query ordered by the whitelisted
+// ?sort= field with the row id as tie breaker. key returns the id and the
+// value of the named sort field for a row so the next cursor can be built
+// from the last one.
+func paginateCursor[T any](c *fiber.Ctx, query *gorm.DB, sortFields map[string]cursorField, fallback string, key func(T, string) (uint, interface{}), preloads ...func(*gorm.DB) *gorm.DB) ([]T, PageMeta, error) {
+	meta := PageMeta{
+		Limit: pageLimit(c),
+		Sort:  c.Query("sort"),
+	}
+	if meta.Sort == "" {
+		meta.Sort = fallback
+	}
+
+	name := strings.TrimPrefix(meta.Sort, "-")
+	field, ok := sortFields[name]
+	if !ok {
+		return nil, meta, pageRequestError{fmt.Sprintf("cannot sort by %s", name)}
+	}
+	descending := strings.HasPrefix(meta.Sort, "-")
+
+	// Qualify the tie breaker the same way as the sort column
+	idColumn := "id"
+	if i := strings.LastIndex(field.Column, "."); i >= 0 {
+		idColumn = field.Column[:i+1] + "id"
+	}
+
+	query = query.Session(&gorm.Session{})
+	var model T
+	if err := query.Model(&model).Count(&meta.Total).Error; err != nil {
+		return nil, meta, err
+	}
+
+	if raw := c.Query("cursor"); raw != "" {
+		cursor, value, err := decodeCursor(raw, field)
+		if err != nil {
+			return nil, meta, err
+		}
+		op := ">"
+		if descending {
+			op = "<"
+		}
+		query = query.Where(
+			fmt.Sprintf("(%s %s ?) OR (%s = ? AND %s %s ?)", field.Column, op, field.Column, idColumn, op),
+			value, value, cursor.ID,
+		)
+	}
+
+	direction := "ASC"
+	if descending {
+		direction = "DESC"
+	}
+
+	var rows []T
+	if err := query.Scopes(preloads...).
+		Order(fmt.Sprintf("%s %s, %s %s", field.Column, direction, idColumn, direction)).
+		Limit(meta.Limit + 1).
+		Find(&rows).Error; err != nil {
+		return nil, meta, err
+	}
+
+	if len(rows) > meta.Limit {
+		rows = rows[:meta.Limit]
+		id, value := key(rows[len(rows)-1], name)
+		meta.NextCursor = encodeCursor(id, value)
+	}
+
+	return rows, meta, nil
+}
+
+func encodeCursor(id uint, value interface{}) string {
+	cursor := pageCursor{ID: id}
+	switch v := value.(type) {
+	case time.Time:
+		cursor.Value = v.Format(time.RFC3339Nano)
+	default:
+		cursor.Value = fmt.Sprint(v)
+	}
+
+	raw, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeCursor(raw string, field cursorField) (pageCursor, interface{}, error) {
+	var cursor pageCursor
+
+	decoded, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return cursor, nil, pageRequestError{"invalid cursor"}
+	}
+	if err := json.Unmarshal(decoded, &cursor); err != nil {
+		return cursor, nil, pageRequestError{"invalid cursor"}
+	}
+
+	if field.Time {
+		t, err := time.Parse(time.RFC3339Nano, cursor.Value)
+		if err != nil {
+			return cursor, nil, pageRequestError{"invalid cursor"}
+		}
+		return cursor, t, nil
+	}
+
+	number, err := strconv.ParseFloat(cursor.Value, 64)
+	if err != nil {
+		return cursor, nil, pageRequestError{"invalid cursor"}
+	}
+	return cursor, number, nil
+}
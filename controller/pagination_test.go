@@ -0,0 +1,80 @@
+package controller
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	bookedAt := time.Date(2025, 3, 14, 19, 30, 0, 123456789, time.UTC)
+
+	tests := []struct {
+		name  string
+		id    uint
+		value interface{}
+		field cursorField
+		want  interface{}
+	}{
+		{"time column", 42, bookedAt, cursorField{Column: "booked_at", Time: true}, bookedAt},
+		{"integer column", 7, 150, cursorField{Column: "duration"}, 150.0},
+		{"float column", 9, 4.5, cursorField{Column: "average_rating"}, 4.5},
+		{"zero value", 1, 0, cursorField{Column: "rating_count"}, 0.0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw := encodeCursor(tt.id, tt.value)
+
+			cursor, value, err := decodeCursor(raw, tt.field)
+			if err != nil {
+				t.Fatalf("decodeCursor(%q) failed: %v", raw, err)
+			}
+			if cursor.ID != tt.id {
+				t.Errorf("id = %d, want %d", cursor.ID, tt.id)
+			}
+
+			switch want := tt.want.(type) {
+			case time.Time:
+				got, ok := value.(time.Time)
+				if !ok || !got.Equal(want) {
+					t.Errorf("value = %v, want %v", value, want)
+				}
+			default:
+				if value != want {
+					t.Errorf("value = %v, want %v", value, want)
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeCursorRejectsBadInput(t *testing.T) {
+	encode := func(json string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(json))
+	}
+
+	tests := []struct {
+		name  string
+		raw   string
+		field cursorField
+	}{
+		{"not base64", "%%%", cursorField{Column: "id"}},
+		{"not json", encode("id=1"), cursorField{Column: "id"}},
+		{"number for a time column", encodeCursor(1, 12), cursorField{Column: "booked_at", Time: true}},
+		{"time for a number column", encodeCursor(1, time.Now()), cursorField{Column: "duration"}},
+		{"empty value", encode(`{"id":1,"value":""}`), cursorField{Column: "duration"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := decodeCursor(tt.raw, tt.field)
+			if err == nil {
+				t.Fatalf("decodeCursor(%q) succeeded, want an error", tt.raw)
+			}
+			if _, ok := err.(pageRequestError); !ok {
+				t.Errorf("error = %T, want pageRequestError", err)
+			}
+		})
+	}
+}
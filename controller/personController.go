@@ -0,0 +1,450 @@
+package controller
+
+import (
+	"strings"
+	"time"
+
+	"github.com/SaharKhamseh/cinema-backend/database"
+	"github.com/SaharKhamseh/cinema-backend/models"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// personSortFields whitelists the columns GetPeople may sort on
+var personSortFields = map[string]string{
+	"name":       "name",
+	"created_at": "created_at",
+}
+
+// CreatePerson adds a cast or crew member
+func CreatePerson(c *fiber.Ctx) error {
+	var data map[string]interface{}
+
+	if err := c.BodyParser(&data); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Invalid request body",
+		})
+	}
+
+	name, _ := data["name"].(string)
+	if strings.TrimSpace(name) == "" {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "name is required",
+		})
+	}
+
+	person := models.Person{ChainID: chainID(c), Name: strings.TrimSpace(name)}
+	if data["biography"] != nil {
+		person.Biography, _ = data["biography"].(string)
+	}
+	if data["photo_url"] != nil {
+		person.PhotoURL, _ = data["photo_url"].(string)
+	}
+
+	tx := database.DB.Begin()
+
+	if err := tx.Create(&person).Error; err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to create person",
+			"error":   err.Error(),
+		})
+	}
+
+	if err := recordAudit(tx, c, "create", "person", person.ID, nil, person); err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to create person",
+			"error":   err.Error(),
+		})
+	}
+
+	tx.Commit()
+
+	return c.Status(201).JSON(fiber.Map{
+		"message": "Person created successfully",
+		"person":  person,
+	})
+}
+
+// GetPeople returns a page of cast and crew, optionally searched by name
+func GetPeople(c *fiber.Ctx) error {
+	query := database.DB.Model(&models.Person{}).Scopes(inChain(c))
+	if q := strings.TrimSpace(c.Query("q")); q != "" {
+		query = query.Where("LOWER(name) LIKE ?", "%"+strings.ToLower(q)+"%")
+	}
+
+	var people []models.Person
+	meta, err := paginateOffset(c, query, &people, personSortFields, "name ASC")
+	if err != nil {
+		return pageErrorResponse(c, err, "Failed to fetch people")
+	}
+
+	return c.JSON(pageEnvelope(people, meta))
+}
+
+// GetPerson returns a person with the movies they worked on and the
+// upcoming show times of each of those movies
+func GetPerson(c *fiber.Ctx) error {
+	id := c.Params("id")
+	var person models.Person
+
+	if err := database.DB.
+		Scopes(inChain(c)).
+		Preload("Credits", func(db *gorm.DB) *gorm.DB {
+			return db.Order("billing_order, id")
+		}).
+		Preload("Credits.Movie").
+		First(&person, id).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"message": "Person not found",
+		})
+	}
+
+	movieIDs := []uint{}
+	seen := map[uint]bool{}
+	for _, credit := range person.Credits {
+		if !seen[credit.MovieID] {
+			seen[credit.MovieID] = true
+			movieIDs = append(movieIDs, credit.MovieID)
+		}
+	}
+
+	var showTimes []models.ShowTime
+	if len(movieIDs) > 0 {
+		if err := database.DB.
+			Preload("Screen").
+			Where("movie_id IN ? AND start_time >= ?", movieIDs, time.Now()).
+			Order("start_time").
+			Find(&showTimes).Error; err != nil {
+			return c.Status(500).JSON(fiber.Map{
+				"message": "Failed to fetch show times",
+				"error":   err.Error(),
+			})
+		}
+	}
+
+	showTimesByMovie := map[uint][]models.ShowTime{}
+	for _, showTime := range showTimes {
+		showTimesByMovie[showTime.MovieID] = append(showTimesByMovie[showTime.MovieID], showTime)
+	}
+
+	movies := make([]fiber.Map, 0, len(movieIDs))
+	for _, movieID := range movieIDs {
+		var movie *models.Movie
+		roles := []models.Credit{}
+		for _, credit := range person.Credits {
+			if credit.MovieID != movieID {
+				continue
+			}
+			movie = credit.Movie
+			credit.Movie = nil
+			roles = append(roles, credit)
+		}
+
+		upcoming := showTimesByMovie[movieID]
+		if upcoming == nil {
+			upcoming = []models.ShowTime{}
+		}
+
+		movies = append(movies, fiber.Map{
+			"movie":     movie,
+			"roles":     roles,
+			"showtimes": upcoming,
+		})
+	}
+
+	person.Credits = nil
+
+	return c.JSON(fiber.Map{
+		"person": person,
+		"movies": movies,
+	})
+}
+
+// UpdatePerson updates a cast or crew member
+func UpdatePerson(c *fiber.Ctx) error {
+	id := c.Params("id")
+	var person models.Person
+
+	if err := database.DB.Scopes(inChain(c)).First(&person, id).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"message": "Person not found",
+		})
+	}
+
+	var data map[string]interface{}
+	if err := c.BodyParser(&data); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Invalid request body",
+		})
+	}
+
+	before := person
+
+	if data["name"] != nil {
+		name, _ := data["name"].(string)
+		if strings.TrimSpace(name) == "" {
+			return c.Status(400).JSON(fiber.Map{
+				"message": "name cannot be empty",
+			})
+		}
+		person.Name = strings.TrimSpace(name)
+	}
+	if data["biography"] != nil {
+		person.Biography, _ = data["biography"].(string)
+	}
+	if data["photo_url"] != nil {
+		person.PhotoURL, _ = data["photo_url"].(string)
+	}
+
+	tx := database.DB.Begin()
+
+	if err := tx.Save(&person).Error; err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to update person",
+			"error":   err.Error(),
+		})
+	}
+
+	if err := recordAudit(tx, c, "update", "person", person.ID, before, person); err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to update person",
+			"error":   err.Error(),
+		})
+	}
+
+	tx.Commit()
+
+	return c.JSON(fiber.Map{
+		"message": "Person updated successfully",
+		"person":  person,
+	})
+}
+
+// DeletePerson removes a person together with their credits
+func DeletePerson(c *fiber.Ctx) error {
+	id := c.Params("id")
+	var person models.Person
+
+	if err := database.DB.Scopes(inChain(c)).First(&person, id).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"message": "Person not found",
+		})
+	}
+
+	tx := database.DB.Begin()
+
+	if err := tx.Where("person_id = ?", person.ID).Delete(&models.Credit{}).Error; err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to delete person",
+			"error":   err.Error(),
+		})
+	}
+
+	if err := tx.Delete(&person).Error; err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to delete person",
+			"error":   err.Error(),
+		})
+	}
+
+	if err := recordAudit(tx, c, "delete", "person", person.ID, person, nil); err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to delete person",
+			"error":   err.Error(),
+		})
+	}
+
+	tx.Commit()
+
+	return c.JSON(fiber.Map{
+		"message": "Person deleted successfully",
+	})
+}
+
+// CreateCredit links a person to a movie with a role
+func CreateCredit(c *fiber.Ctx) error {
+	movieID := c.Params("id")
+	var movie models.Movie
+
+	if err := database.DB.Scopes(inChain(c)).First(&movie, movieID).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"message": "Movie not found",
+		})
+	}
+
+	var data map[string]interface{}
+	if err := c.BodyParser(&data); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Invalid request body",
+		})
+	}
+
+	// Validate required fields
+	requiredFields := []string{"person_id", "role"}
+	for _, field := range requiredFields {
+		if data[field] == nil {
+			return c.Status(400).JSON(fiber.Map{
+				"message": field + " is required",
+			})
+		}
+	}
+
+	var person models.Person
+	if err := database.DB.Scopes(inChain(c)).First(&person, data["person_id"]).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"message": "Person not found",
+		})
+	}
+
+	role, _ := data["role"].(string)
+	credit := models.Credit{
+		MovieID:  movie.ID,
+		PersonID: person.ID,
+		Role:     strings.ToLower(strings.TrimSpace(role)),
+	}
+	if credit.Role == "" {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "role cannot be empty",
+		})
+	}
+	if data["character_name"] != nil {
+		credit.CharacterName, _ = data["character_name"].(string)
+	}
+	if data["billing_order"] != nil {
+		order, _ := data["billing_order"].(float64)
+		credit.BillingOrder = int(order)
+	}
+
+	tx := database.DB.Begin()
+
+	if err := tx.Create(&credit).Error; err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to create credit",
+			"error":   err.Error(),
+		})
+	}
+
+	if err := recordAudit(tx, c, "create", "credit", credit.ID, nil, credit); err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to create credit",
+			"error":   err.Error(),
+		})
+	}
+
+	tx.Commit()
+
+	credit.Person = &person
+
+	return c.Status(201).JSON(fiber.Map{
+		"message": "Credit created successfully",
+		"credit":  credit,
+	})
+}
+
+// UpdateCredit changes the role, character or billing order of a credit
+func UpdateCredit(c *fiber.Ctx) error {
+	id := c.Params("id")
+	var credit models.Credit
+
+	if err := database.DB.Scopes(movieDataInChain(c)).First(&credit, id).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"message": "Credit not found",
+		})
+	}
+
+	var data map[string]interface{}
+	if err := c.BodyParser(&data); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Invalid request body",
+		})
+	}
+
+	before := credit
+
+	if data["role"] != nil {
+		role, _ := data["role"].(string)
+		if strings.TrimSpace(role) == "" {
+			return c.Status(400).JSON(fiber.Map{
+				"message": "role cannot be empty",
+			})
+		}
+		credit.Role = strings.ToLower(strings.TrimSpace(role))
+	}
+	if data["character_name"] != nil {
+		credit.CharacterName, _ = data["character_name"].(string)
+	}
+	if data["billing_order"] != nil {
+		order, _ := data["billing_order"].(float64)
+		credit.BillingOrder = int(order)
+	}
+
+	tx := database.DB.Begin()
+
+	if err := tx.Save(&credit).Error; err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to update credit",
+			"error":   err.Error(),
+		})
+	}
+
+	if err := recordAudit(tx, c, "update", "credit", credit.ID, before, credit); err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to update credit",
+			"error":   err.Error(),
+		})
+	}
+
+	tx.Commit()
+
+	return c.JSON(fiber.Map{
+		"message": "Credit updated successfully",
+		"credit":  credit,
+	})
+}
+
+// DeleteCredit removes a person from a movie
+func DeleteCredit(c *fiber.Ctx) error {
+	id := c.Params("id")
+	var credit models.Credit
+
+	if err := database.DB.Scopes(movieDataInChain(c)).First(&credit, id).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"message": "Credit not found",
+		})
+	}
+
+	tx := database.DB.Begin()
+
+	if err := tx.Delete(&credit).Error; err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to delete credit",
+			"error":   err.Error(),
+		})
+	}
+
+	if err := recordAudit(tx, c, "delete", "credit", credit.ID, credit, nil); err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to delete credit",
+			"error":   err.Error(),
+		})
+	}
+
+	tx.Commit()
+
+	return c.JSON(fiber.Map{
+		"message": "Credit deleted successfully",
+	})
+}
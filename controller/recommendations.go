@@ -0,0 +1,404 @@
+package controller
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/SaharKhamseh/cinema-backend/database"
+	"github.com/SaharKhamseh/cinema-backend/models"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// recommendationLimit is how many movies are cached per user
+const recommendationLimit = 20
+
+// Weights of the ranking signals, they add up to 1
+const (
+	coAttendanceWeight = 0.5
+	genreWeight        = 0.3
+	languageWeight     = 0.15
+	popularityWeight   = 0.05
+)
+
+// recommendationData is the booking history the ranking works from
+type recommendationData struct {
+	watched  map[uint]map[uint]bool // user -> movies booked
+	audience map[uint]int           // movie -> users who booked it
+	together map[[2]uint]int        // movie pair -> users who booked both
+	movies   map[uint]models.Movie
+	upcoming []uint // movies with show times that have not started
+}
+
+func moviePair(a, b uint) [2]uint {
+	if a > b {
+		a, b = b, a
+	}
+	return [2]uint{a, b}
+}
+
+// loadRecommendationData reads every non-cancelled booking and the movies
+// that have upcoming show times
+func loadRecommendationData() (*recommendationData, error) {
+	data := &recommendationData{
+		watched:  map[uint]map[uint]bool{},
+		audience: map[uint]int{},
+		together: map[[2]uint]int{},
+		movies:   map[uint]models.Movie{},
+	}
+
+	var history []struct {
+		UserID  uint
+		MovieID uint
+	}
+	if err := database.DB.Table("bookings").
+		Select("DISTINCT bookings.user_id, show_times.movie_id").
+		Joins("JOIN show_times ON show_times.id = bookings.show_time_id").
+		Where("bookings.status != ?", "cancelled").
+		Scan(&history).Error; err != nil {
+		return nil, err
+	}
+
+	for _, row := range history {
+		if data.watched[row.UserID] == nil {
+			data.watched[row.UserID] = map[uint]bool{}
+		}
+		data.watched[row.UserID][row.MovieID] = true
+		data.audience[row.MovieID]++
+	}
+
+	for _, movies := range data.watched {
+		ids := make([]uint, 0, len(movies))
+		for id := range movies {
+			ids = append(ids, id)
+		}
+		for i := range ids {
+			for j := i + 1; j < len(ids); j++ {
+				data.together[moviePair(ids[i], ids[j])]++
+			}
+		}
+	}
+
+	if err := database.DB.Model(&models.ShowTime{}).
+		Distinct("movie_id").
+		Where("start_time > ?", time.Now()).
+		Pluck("movie_id", &data.upcoming).Error; err != nil {
+		return nil, err
+	}
+
+	ids := append([]uint{}, data.upcoming...)
+	for id := range data.audience {
+		ids = append(ids, id)
+	}
+
+	// Watched movies may have been deleted since, they still describe taste
+	var movies []models.Movie
+	if len(ids) > 0 {
+		if err := database.DB.Unscoped().Preload("Genres").Where("id IN ?", ids).Find(&movies).Error; err != nil {
+			return nil, err
+		}
+	}
+	for _, movie := range movies {
+		data.movies[movie.ID] = movie
+	}
+
+	return data, nil
+}
+
+// rank scores the upcoming movies of the user's chain that they have not
+// booked yet. Users without history get the most booked upcoming movies.
+func (data *recommendationData) rank(userID, chainID uint) []models.Recommendation {
+	watched := data.watched[userID]
+
+	genreProfile := map[uint]float64{}
+	languageProfile := map[string]float64{}
+	for movieID := range watched {
+		movie := data.movies[movieID]
+		for _, genre := range movie.Genres {
+			genreProfile[genre.ID] += 1 / float64(len(watched))
+		}
+		if movie.Language != "" {
+			languageProfile[strings.ToLower(movie.Language)] += 1 / float64(len(watched))
+		}
+	}
+
+	maxAudience := 1
+	for _, movieID := range data.upcoming {
+		if data.audience[movieID] > maxAudience {
+			maxAudience = data.audience[movieID]
+		}
+	}
+
+	now := time.Now()
+	var results []models.Recommendation
+
+	for _, movieID := range data.upcoming {
+		movie, ok := data.movies[movieID]
+		if !ok || movie.ChainID != chainID || watched[movieID] {
+			continue
+		}
+
+		// Co-attendance: cosine similarity with the movies the user booked
+		var coAttendance, bestPair float64
+		var pairedWith uint
+		for watchedID := range watched {
+			both := data.together[moviePair(watchedID, movieID)]
+			if both == 0 {
+				continue
+			}
+			similarity := float64(both) / math.Sqrt(float64(data.audience[watchedID]*data.audience[movieID]))
+			coAttendance += similarity
+			if similarity > bestPair {
+				bestPair, pairedWith = similarity, watchedID
+			}
+		}
+		coAttendance = math.Min(coAttendance, 1)
+
+		var genre float64
+		for _, g := range movie.Genres {
+			genre += genreProfile[g.ID]
+		}
+		if len(movie.Genres) > 0 {
+			genre /= float64(len(movie.Genres))
+		}
+
+		language := languageProfile[strings.ToLower(movie.Language)]
+		popularity := float64(data.audience[movieID]) / float64(maxAudience)
+
+		signals := map[string]float64{
+			"co_attendance": coAttendanceWeight * coAttendance,
+			"genre":         genreWeight * genre,
+			"language":      languageWeight * language,
+			"popular":       popularityWeight * popularity,
+		}
+
+		recommendation := models.Recommendation{
+			UserID:     userID,
+			MovieID:    movieID,
+			Reason:     "popular",
+			ComputedAt: now,
+		}
+		strongest := -1.0
+		for _, reason := range []string{"co_attendance", "genre", "language", "popular"} {
+			recommendation.Score += signals[reason]
+			if signals[reason] > strongest {
+				strongest = signals[reason]
+				recommendation.Reason = reason
+			}
+		}
+
+		switch recommendation.Reason {
+		case "co_attendance":
+			recommendation.BecauseMovieID = &pairedWith
+		case "genre":
+			recommendation.BecauseMovieID = data.closestByGenre(watched, movie)
+		}
+
+		results = append(results, recommendation)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].MovieID < results[j].MovieID
+	})
+
+	if len(results) > recommendationLimit {
+		results = results[:recommendationLimit]
+	}
+	for i := range results {
+		results[i].Rank = i + 1
+	}
+	return results
+}
+
+// closestByGenre picks the watched movie sharing the most genres with movie
+func (data *recommendationData) closestByGenre(watched map[uint]bool, movie models.Movie) *uint {
+	genres := map[uint]bool{}
+	for _, genre := range movie.Genres {
+		genres[genre.ID] = true
+	}
+
+	var best *uint
+	bestShared := 0
+	for watchedID := range watched {
+		shared := 0
+		for _, genre := range data.movies[watchedID].Genres {
+			if genres[genre.ID] {
+				shared++
+			}
+		}
+		if shared > bestShared || (shared == bestShared && shared > 0 && watchedID < *best) {
+			id := watchedID
+			best, bestShared = &id, shared
+		}
+	}
+	return best
+}
+
+// RefreshRecommendations recomputes and caches the recommendations of the
+// given users, or of every user when none are given. It is meant to run
+// offline, see the recommend command.
+func RefreshRecommendations(userIDs ...uint) (int, error) {
+	data, err := loadRecommendationData()
+	if err != nil {
+		return 0, err
+	}
+
+	var users []models.User
+	query := database.DB.Select("id", "chain_id")
+	if len(userIDs) > 0 {
+		query = query.Where("id IN ?", userIDs)
+	}
+	if err := query.Find(&users).Error; err != nil {
+		return 0, err
+	}
+
+	for _, user := range users {
+		results := data.rank(user.Id, user.ChainID)
+
+		tx := database.DB.Begin()
+		if err := tx.Where("user_id = ?", user.Id).Delete(&models.Recommendation{}).Error; err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+		if len(results) > 0 {
+			if err := tx.Omit("Movie", "BecauseMovie").Create(&results).Error; err != nil {
+				tx.Rollback()
+				return 0, err
+			}
+		}
+		if err := tx.Commit().Error; err != nil {
+			return 0, err
+		}
+	}
+
+	return len(users), nil
+}
+
+// popularRecommendations ranks the upcoming movies of the chain by how many
+// users booked them. It stands in for users the recommend command has not
+// cached anything for, without loading the whole booking history.
+func popularRecommendations(c *fiber.Ctx, userID uint, limit int) ([]models.Recommendation, error) {
+	now := time.Now()
+
+	var rows []struct {
+		MovieID  uint
+		Audience int
+	}
+	if err := database.DB.Table("show_times").
+		Select("show_times.movie_id, COUNT(DISTINCT bookings.user_id) AS audience").
+		Joins("LEFT JOIN bookings ON bookings.show_time_id = show_times.id AND bookings.status != ?", "cancelled").
+		Where("show_times.movie_id IN (SELECT movie_id FROM show_times WHERE start_time > ? AND deleted_at IS NULL)", now).
+		Where("show_times.movie_id IN (?)", database.DB.Model(&models.Movie{}).Scopes(inChain(c)).Select("id")).
+		Group("show_times.movie_id").
+		Order("audience DESC, show_times.movie_id").
+		Limit(limit).
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return []models.Recommendation{}, nil
+	}
+
+	ids := make([]uint, len(rows))
+	for i, row := range rows {
+		ids[i] = row.MovieID
+	}
+	var movies []models.Movie
+	if err := database.DB.Where("id IN ?", ids).Find(&movies).Error; err != nil {
+		return nil, err
+	}
+	byID := map[uint]models.Movie{}
+	for _, movie := range movies {
+		byID[movie.ID] = movie
+	}
+
+	maxAudience := max(rows[0].Audience, 1)
+	recommendations := make([]models.Recommendation, 0, len(rows))
+	for _, row := range rows {
+		movie, ok := byID[row.MovieID]
+		if !ok {
+			continue
+		}
+		recommendations = append(recommendations, models.Recommendation{
+			UserID:     userID,
+			MovieID:    row.MovieID,
+			Movie:      movie,
+			Rank:       len(recommendations) + 1,
+			Score:      popularityWeight * float64(row.Audience) / float64(maxAudience),
+			Reason:     "popular",
+			ComputedAt: now,
+		})
+	}
+	return recommendations, nil
+}
+
+// GetMyRecommendations returns the cached recommendations of the logged-in
+// user, skipping movies that no longer have upcoming show times. Users the
+// recommend command has not reached yet get the most booked upcoming movies.
+func GetMyRecommendations(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{
+			"message": "Unauthorized",
+		})
+	}
+
+	limit := c.QueryInt("limit", recommendationLimit)
+	if limit < 1 || limit > recommendationLimit {
+		limit = recommendationLimit
+	}
+
+	var cached int64
+	database.DB.Model(&models.Recommendation{}).Where("user_id = ?", userID).Count(&cached)
+	if cached == 0 {
+		recommendations, err := popularRecommendations(c, userID, limit)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{
+				"message": "Failed to fetch recommendations",
+				"error":   err.Error(),
+			})
+		}
+		movies := make([]*models.Movie, len(recommendations))
+		for i := range recommendations {
+			movies[i] = &recommendations[i].Movie
+		}
+		localizeMovies(c, movies...)
+
+		return c.JSON(fiber.Map{
+			"data": recommendations,
+		})
+	}
+
+	var recommendations []models.Recommendation
+	if err := database.DB.
+		Preload("Movie").
+		Preload("BecauseMovie", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Where("user_id = ?", userID).
+		Where("movie_id IN (SELECT movie_id FROM show_times WHERE start_time > ? AND deleted_at IS NULL)", time.Now()).
+		Order("`rank`").
+		Limit(limit).
+		Find(&recommendations).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to fetch recommendations",
+			"error":   err.Error(),
+		})
+	}
+
+	movies := make([]*models.Movie, 0, len(recommendations)*2)
+	for i := range recommendations {
+		movies = append(movies, &recommendations[i].Movie)
+		if recommendations[i].BecauseMovie != nil {
+			movies = append(movies, recommendations[i].BecauseMovie)
+		}
+	}
+	localizeMovies(c, movies...)
+
+	return c.JSON(fiber.Map{
+		"data": recommendations,
+	})
+}
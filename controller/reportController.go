@@ -0,0 +1,237 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/SaharKhamseh/cinema-backend/database"
+	"github.com/SaharKhamseh/cinema-backend/models"
+	"github.com/gofiber/fiber/v2"
+)
+
+// SalesRow is one aggregated line of a sales report
+type SalesRow struct {
+	Key      string  `json:"key"`
+	Label    string  `json:"label"`
+	Bookings int     `json:"bookings"`
+	Tickets  int     `json:"tickets"`
+	Gross    float64 `json:"gross"`
+	Refunds  float64 `json:"refunds"`
+	Net      float64 `json:"net"`
+}
+
+var salesDimensions = map[string]bool{
+	"movie":    true,
+	"theater":  true,
+	"screen":   true,
+	"showtime": true,
+	"category": true,
+	"day":      true,
+	"week":     true,
+	"month":    true,
+}
+
+// parseDateRange reads the optional from/to query parameters (YYYY-MM-DD).
+// The returned "to" is exclusive, so to=2025-01-31 covers the whole day.
+func parseDateRange(c *fiber.Ctx) (time.Time, time.Time, error) {
+	var from, to time.Time
+
+	if v := c.Query("from"); v != "" {
+		t, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			return from, to, fmt.Errorf("invalid from date format. Use YYYY-MM-DD")
+		}
+		from = t
+	}
+
+	if v := c.Query("to"); v != "" {
+		t, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			return from, to, fmt.Errorf("invalid to date format. Use YYYY-MM-DD")
+		}
+		to = t.Add(24 * time.Hour)
+	}
+
+	return from, to, nil
+}
+
+// GetSalesReport aggregates revenue and ticket counts by the requested
+// dimension. Cancelled bookings are left out; refunds on bookings that are
+// still live are subtracted from the net.
+func GetSalesReport(c *fiber.Ctx) error {
+	dimension := c.Params("dimension")
+	if !salesDimensions[dimension] {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Unknown report dimension. Use movie, theater, screen, showtime, category, day, week or month",
+		})
+	}
+
+	from, to, err := parseDateRange(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	query := database.DB.Scopes(bookingsInChain(c), bookingHistory)
+	if !from.IsZero() {
+		query = query.Where("booked_at >= ?", from)
+	}
+	if !to.IsZero() {
+		query = query.Where("booked_at < ?", to)
+	}
+
+	var bookings []models.Booking
+	if err := query.Find(&bookings).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to fetch bookings",
+			"error":   err.Error(),
+		})
+	}
+
+	theaterNames := map[uint]string{}
+	if dimension == "theater" {
+		var theaters []models.Theater
+		database.DB.Unscoped().Scopes(inChain(c)).Find(&theaters)
+		for _, theater := range theaters {
+			theaterNames[theater.ID] = theater.Name
+		}
+	}
+
+	rows := aggregateSales(bookings, dimension, theaterNames)
+
+	if c.Query("format") == "csv" {
+		return sendSalesCSV(c, dimension, rows)
+	}
+
+	return c.JSON(fiber.Map{
+		"dimension": dimension,
+		"rows":      rows,
+		"totals":    salesTotals(rows),
+	})
+}
+
+// aggregateSales groups bookings into report rows, skipping cancelled ones.
+// For the category dimension a booking's amounts are split evenly across
+// its seats.
+func aggregateSales(bookings []models.Booking, dimension string, theaterNames map[uint]string) []SalesRow {
+	index := map[string]*SalesRow{}
+	var keys []string
+
+	add := func(key, label string, bookings, tickets int, gross, refunds float64) {
+		row, ok := index[key]
+		if !ok {
+			row = &SalesRow{Key: key, Label: label}
+			index[key] = row
+			keys = append(keys, key)
+		}
+		row.Bookings += bookings
+		row.Tickets += tickets
+		row.Gross += gross
+		row.Refunds += refunds
+		row.Net = row.Gross - row.Refunds
+	}
+
+	for _, booking := range bookings {
+		if booking.Status == "cancelled" {
+			continue
+		}
+		tickets := len(booking.Seats)
+		showTime := booking.ShowTime
+
+		switch dimension {
+		case "movie":
+			add(strconv.Itoa(int(showTime.MovieID)), showTime.Movie.Title, 1, tickets, booking.TotalPrice, booking.RefundedAmount)
+		case "theater":
+			theaterID := showTime.Screen.TheaterID
+			add(strconv.Itoa(int(theaterID)), theaterNames[theaterID], 1, tickets, booking.TotalPrice, booking.RefundedAmount)
+		case "screen":
+			add(strconv.Itoa(int(showTime.ScreenID)), showTime.Screen.Name, 1, tickets, booking.TotalPrice, booking.RefundedAmount)
+		case "showtime":
+			label := fmt.Sprintf("%s @ %s", showTime.Movie.Title, showTime.StartTime.Format("2006-01-02 15:04"))
+			add(strconv.Itoa(int(showTime.ID)), label, 1, tickets, booking.TotalPrice, booking.RefundedAmount)
+		case "category":
+			if tickets == 0 {
+				continue
+			}
+			share := booking.TotalPrice / float64(tickets)
+			refundShare := booking.RefundedAmount / float64(tickets)
+			counted := map[string]bool{}
+			for _, seat := range booking.Seats {
+				category := seat.Category
+				if category == "" {
+					category = "standard"
+				}
+				bookingCount := 0
+				if !counted[category] {
+					counted[category] = true
+					bookingCount = 1
+				}
+				add(category, category, bookingCount, 1, share, refundShare)
+			}
+		case "day":
+			day := booking.BookedAt.Format("2006-01-02")
+			add(day, day, 1, tickets, booking.TotalPrice, booking.RefundedAmount)
+		case "week":
+			year, week := booking.BookedAt.ISOWeek()
+			key := fmt.Sprintf("%d-W%02d", year, week)
+			add(key, key, 1, tickets, booking.TotalPrice, booking.RefundedAmount)
+		case "month":
+			month := booking.BookedAt.Format("2006-01")
+			add(month, month, 1, tickets, booking.TotalPrice, booking.RefundedAmount)
+		}
+	}
+
+	sort.Strings(keys)
+	rows := make([]SalesRow, 0, len(keys))
+	for _, key := range keys {
+		rows = append(rows, *index[key])
+	}
+	return rows
+}
+
+func salesTotals(rows []SalesRow) SalesRow {
+	totals := SalesRow{Key: "total", Label: "Total"}
+	for _, row := range rows {
+		totals.Bookings += row.Bookings
+		totals.Tickets += row.Tickets
+		totals.Gross += row.Gross
+		totals.Refunds += row.Refunds
+	}
+	totals.Net = totals.Gross - totals.Refunds
+	return totals
+}
+
+func sendSalesCSV(c *fiber.Ctx, dimension string, rows []SalesRow) error {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	w.Write([]string{dimension, "label", "bookings", "tickets", "gross", "refunds", "net"})
+	for _, row := range append(rows, salesTotals(rows)) {
+		w.Write([]string{
+			row.Key,
+			row.Label,
+			strconv.Itoa(row.Bookings),
+			strconv.Itoa(row.Tickets),
+			strconv.FormatFloat(row.Gross, 'f', 2, 64),
+			strconv.FormatFloat(row.Refunds, 'f', 2, 64),
+			strconv.FormatFloat(row.Net, 'f', 2, 64),
+		})
+	}
+	w.Flush()
+
+	if err := w.Error(); err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to write report",
+			"error":   err.Error(),
+		})
+	}
+
+	c.Set(fiber.HeaderContentType, "text/csv")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf("attachment; filename=\"sales-%s.csv\"", dimension))
+	return c.Send(buf.Bytes())
+}
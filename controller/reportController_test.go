@@ -0,0 +1,69 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/SaharKhamseh/cinema-backend/models"
+)
+
+func TestAggregateSales(t *testing.T) {
+	day1 := time.Date(2025, 1, 6, 18, 0, 0, 0, time.UTC)
+	day2 := time.Date(2025, 1, 7, 18, 0, 0, 0, time.UTC)
+	show := models.ShowTime{ID: 3, MovieID: 1, Movie: models.Movie{Title: "Arrival"}, ScreenID: 2, StartTime: day2}
+
+	bookings := []models.Booking{
+		{ShowTime: show, BookedAt: day1, TotalPrice: 30, Seats: []models.Seat{{Category: "premium"}, {Category: ""}}},
+		{ShowTime: show, BookedAt: day1, TotalPrice: 10, Seats: []models.Seat{{Category: "standard"}}},
+		{ShowTime: show, BookedAt: day2, TotalPrice: 24, RefundedAmount: 8, Status: "pending",
+			Seats: []models.Seat{{Category: "premium"}, {Category: "standard"}}},
+		{ShowTime: show, BookedAt: day2, TotalPrice: 20, RefundedAmount: 20, Status: "cancelled",
+			Seats: []models.Seat{{Category: "premium"}, {Category: "premium"}}},
+	}
+
+	tests := []struct {
+		dimension string
+		want      []SalesRow
+	}{
+		{"movie", []SalesRow{
+			{Key: "1", Label: "Arrival", Bookings: 3, Tickets: 5, Gross: 64, Refunds: 8, Net: 56},
+		}},
+		{"day", []SalesRow{
+			{Key: "2025-01-06", Label: "2025-01-06", Bookings: 2, Tickets: 3, Gross: 40, Net: 40},
+			{Key: "2025-01-07", Label: "2025-01-07", Bookings: 1, Tickets: 2, Gross: 24, Refunds: 8, Net: 16},
+		}},
+		{"category", []SalesRow{
+			{Key: "premium", Label: "premium", Bookings: 2, Tickets: 2, Gross: 27, Refunds: 4, Net: 23},
+			{Key: "standard", Label: "standard", Bookings: 3, Tickets: 3, Gross: 37, Refunds: 4, Net: 33},
+		}},
+		{"week", []SalesRow{
+			{Key: "2025-W02", Label: "2025-W02", Bookings: 3, Tickets: 5, Gross: 64, Refunds: 8, Net: 56},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dimension, func(t *testing.T) {
+			got := aggregateSales(bookings, tt.dimension, nil)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d rows %+v, want %d", len(got), got, len(tt.want))
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("row %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSalesTotals(t *testing.T) {
+	totals := salesTotals([]SalesRow{
+		{Bookings: 2, Tickets: 3, Gross: 40, Refunds: 0, Net: 40},
+		{Bookings: 1, Tickets: 2, Gross: 20, Refunds: 20, Net: 0},
+	})
+
+	want := SalesRow{Key: "total", Label: "Total", Bookings: 3, Tickets: 5, Gross: 60, Refunds: 20, Net: 40}
+	if totals != want {
+		t.Errorf("salesTotals = %+v, want %+v", totals, want)
+	}
+}
@@ -0,0 +1,412 @@
+package controller
+
+import (
+	"strings"
+	"time"
+
+	"github.com/SaharKhamseh/cinema-backend/database"
+	"github.com/SaharKhamseh/cinema-backend/models"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// reviewSortFields whitelists the columns review lists may sort on
+var reviewSortFields = map[string]string{
+	"created_at": "created_at",
+	"rating":     "rating",
+}
+
+// reviewAuthor preloads the review's user limited to the public name fields
+func reviewAuthor(db *gorm.DB) *gorm.DB {
+	return db.Preload("User", func(db *gorm.DB) *gorm.DB {
+		return db.Select("id", "first_name", "last_name")
+	})
+}
+
+// hasAttended reports whether the user holds a booking that was not
+// cancelled for a show of the movie that has already ended.
+//
+// The review request asks for a confirmed booking, but bookings are created
+// pending and there is no payment or confirmation step that moves them on.
+// Any booking that was not cancelled is therefore taken as attendance, the
+// same rule the sales and occupancy reports use. Once bookings get
+// confirmed, this should require status "confirmed" instead.
+func hasAttended(userID, movieID uint) bool {
+	var count int64
+	database.DB.Model(&models.Booking{}).
+		Joins("JOIN show_times ON show_times.id = bookings.show_time_id").
+		Where("bookings.user_id = ? AND bookings.status != ? AND show_times.movie_id = ? AND show_times.end_time < ?",
+			userID, "cancelled", movieID, time.Now()).
+		Count(&count)
+	return count > 0
+}
+
+// refreshMovieRating recomputes the aggregate rating of a movie from its
+// published reviews
+func refreshMovieRating(tx *gorm.DB, movieID uint) error {
+	var aggregate struct {
+		Average float64
+		Total   int
+	}
+	if err := tx.Model(&models.Review{}).
+		Select("COALESCE(AVG(rating), 0) AS average, COUNT(*) AS total").
+		Where("movie_id = ? AND status = ?", movieID, "published").
+		Scan(&aggregate).Error; err != nil {
+		return err
+	}
+
+	return tx.Model(&models.Movie{}).Where("id = ?", movieID).Updates(map[string]interface{}{
+		"average_rating": aggregate.Average,
+		"rating_count":   aggregate.Total,
+	}).Error
+}
+
+// parseRating validates the rating field of a review request
+func parseRating(value interface{}) (int, bool) {
+	number, ok := value.(float64)
+	if !ok || number != float64(int(number)) || number < 1 || number > 5 {
+		return 0, false
+	}
+	return int(number), true
+}
+
+// GetMovieReviews returns a page of the published reviews of a movie
+func GetMovieReviews(c *fiber.Ctx) error {
+	movieID := c.Params("id")
+
+	query := database.DB.Model(&models.Review{}).
+		Scopes(movieDataInChain(c)).
+		Where("movie_id = ? AND status = ?", movieID, "published")
+
+	var reviews []models.Review
+	meta, err := paginateOffset(c, query, &reviews, reviewSortFields, "created_at DESC", reviewAuthor)
+	if err != nil {
+		return pageErrorResponse(c, err, "Failed to fetch reviews")
+	}
+
+	return c.JSON(pageEnvelope(reviews, meta))
+}
+
+// CreateReview lets a user who attended a show of the movie review it
+func CreateReview(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{
+			"message": "Unauthorized",
+		})
+	}
+
+	movieID := c.Params("id")
+	var movie models.Movie
+
+	if err := database.DB.Scopes(inChain(c)).First(&movie, movieID).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"message": "Movie not found",
+		})
+	}
+
+	var data map[string]interface{}
+	if err := c.BodyParser(&data); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Invalid request body",
+		})
+	}
+
+	rating, ok := parseRating(data["rating"])
+	if !ok {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "rating must be a whole number from 1 to 5",
+		})
+	}
+
+	if !hasAttended(userID, movie.ID) {
+		return c.Status(403).JSON(fiber.Map{
+			"message": "Only customers who attended a show of this movie can review it",
+		})
+	}
+
+	var existing int64
+	database.DB.Model(&models.Review{}).Where("movie_id = ? AND user_id = ?", movie.ID, userID).Count(&existing)
+	if existing > 0 {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "You have already reviewed this movie",
+		})
+	}
+
+	text, _ := data["text"].(string)
+	review := models.Review{
+		MovieID: movie.ID,
+		UserID:  userID,
+		Rating:  rating,
+		Text:    strings.TrimSpace(text),
+		Status:  "published",
+	}
+
+	tx := database.DB.Begin()
+
+	if err := tx.Create(&review).Error; err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to create review",
+			"error":   err.Error(),
+		})
+	}
+
+	if err := refreshMovieRating(tx, movie.ID); err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to create review",
+			"error":   err.Error(),
+		})
+	}
+
+	tx.Commit()
+
+	return c.Status(201).JSON(fiber.Map{
+		"message": "Review created successfully",
+		"review":  review,
+	})
+}
+
+// UpdateReview lets the author change their rating or text
+func UpdateReview(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{
+			"message": "Unauthorized",
+		})
+	}
+
+	id := c.Params("id")
+	var review models.Review
+
+	if err := database.DB.Scopes(movieDataInChain(c)).First(&review, id).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"message": "Review not found",
+		})
+	}
+
+	if review.UserID != userID {
+		return c.Status(403).JSON(fiber.Map{
+			"message": "Unauthorized to edit this review",
+		})
+	}
+
+	var data map[string]interface{}
+	if err := c.BodyParser(&data); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Invalid request body",
+		})
+	}
+
+	if data["rating"] != nil {
+		rating, ok := parseRating(data["rating"])
+		if !ok {
+			return c.Status(400).JSON(fiber.Map{
+				"message": "rating must be a whole number from 1 to 5",
+			})
+		}
+		review.Rating = rating
+	}
+	if data["text"] != nil {
+		text, _ := data["text"].(string)
+		review.Text = strings.TrimSpace(text)
+	}
+
+	tx := database.DB.Begin()
+
+	if err := tx.Save(&review).Error; err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to update review",
+			"error":   err.Error(),
+		})
+	}
+
+	if err := refreshMovieRating(tx, review.MovieID); err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to update review",
+			"error":   err.Error(),
+		})
+	}
+
+	tx.Commit()
+
+	return c.JSON(fiber.Map{
+		"message": "Review updated successfully",
+		"review":  review,
+	})
+}
+
+// DeleteReview lets the author remove their review
+func DeleteReview(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{
+			"message": "Unauthorized",
+		})
+	}
+
+	id := c.Params("id")
+	var review models.Review
+
+	if err := database.DB.Scopes(movieDataInChain(c)).First(&review, id).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"message": "Review not found",
+		})
+	}
+
+	if review.UserID != userID {
+		return c.Status(403).JSON(fiber.Map{
+			"message": "Unauthorized to delete this review",
+		})
+	}
+
+	tx := database.DB.Begin()
+
+	if err := tx.Delete(&review).Error; err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to delete review",
+			"error":   err.Error(),
+		})
+	}
+
+	if err := refreshMovieRating(tx, review.MovieID); err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to delete review",
+			"error":   err.Error(),
+		})
+	}
+
+	tx.Commit()
+
+	return c.JSON(fiber.Map{
+		"message": "Review deleted successfully",
+	})
+}
+
+// ReportReview flags a review as abusive so it shows up in the moderation queue
+func ReportReview(c *fiber.Ctx) error {
+	id := c.Params("id")
+	var review models.Review
+
+	if err := database.DB.Scopes(movieDataInChain(c)).First(&review, id).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"message": "Review not found",
+		})
+	}
+
+	if err := database.DB.Model(&review).
+		UpdateColumn("report_count", gorm.Expr("report_count + ?", 1)).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to report review",
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Review reported successfully",
+	})
+}
+
+// GetModerationQueue lists reviews for moderators. By default it returns
+// reported reviews that have not been moderated yet, most reported first;
+// ?status=hidden or ?status=published lists moderated reviews instead.
+func GetModerationQueue(c *fiber.Ctx) error {
+	query := database.DB.Model(&models.Review{}).Scopes(movieDataInChain(c))
+
+	switch status := c.Query("status"); status {
+	case "":
+		query = query.Where("status = ? AND report_count > 0 AND moderated_at IS NULL", "published")
+	case "published", "hidden":
+		query = query.Where("status = ?", status)
+	default:
+		return c.Status(400).JSON(fiber.Map{
+			"message": "status must be published or hidden",
+		})
+	}
+
+	sortFields := map[string]string{
+		"created_at":   "created_at",
+		"rating":       "rating",
+		"report_count": "report_count",
+	}
+
+	var reviews []models.Review
+	meta, err := paginateOffset(c, query, &reviews, sortFields, "report_count DESC, created_at ASC", reviewAuthor)
+	if err != nil {
+		return pageErrorResponse(c, err, "Failed to fetch reviews")
+	}
+
+	return c.JSON(pageEnvelope(reviews, meta))
+}
+
+// ModerateReview hides or republishes a review
+func ModerateReview(c *fiber.Ctx) error {
+	id := c.Params("id")
+	var review models.Review
+
+	if err := database.DB.Scopes(movieDataInChain(c)).First(&review, id).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"message": "Review not found",
+		})
+	}
+
+	var data map[string]interface{}
+	if err := c.BodyParser(&data); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Invalid request body",
+		})
+	}
+
+	status, _ := data["status"].(string)
+	if status != "published" && status != "hidden" {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "status must be published or hidden",
+		})
+	}
+
+	moderatorID, _ := currentUserID(c)
+	now := time.Now()
+
+	before := review
+	review.Status = status
+	review.ModeratedBy = moderatorID
+	review.ModeratedAt = &now
+
+	tx := database.DB.Begin()
+
+	if err := tx.Save(&review).Error; err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to moderate review",
+			"error":   err.Error(),
+		})
+	}
+
+	if err := refreshMovieRating(tx, review.MovieID); err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to moderate review",
+			"error":   err.Error(),
+		})
+	}
+
+	if err := recordAudit(tx, c, "update", "review", review.ID, before, review); err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to moderate review",
+			"error":   err.Error(),
+		})
+	}
+
+	tx.Commit()
+
+	return c.JSON(fiber.Map{
+		"message": "Review moderated successfully",
+		"review":  review,
+	})
+}
@@ -0,0 +1,181 @@
+package controller
+
+import (
+	"github.com/SaharKhamseh/cinema-backend/database"
+	"github.com/SaharKhamseh/cinema-backend/models"
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetTheaterRoles lists the staff of a theater with their roles
+func GetTheaterRoles(c *fiber.Ctx) error {
+	var theater models.Theater
+	if err := database.DB.Scopes(inChain(c)).First(&theater, c.Params("id")).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"message": "Theater not found",
+		})
+	}
+
+	var assignments []models.RoleAssignment
+	if err := database.DB.
+		Preload("User").
+		Where("theater_id = ?", theater.ID).
+		Order("role, user_id").
+		Find(&assignments).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to fetch roles",
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"data":        assignments,
+		"permissions": models.RolePermissions,
+	})
+}
+
+// GetMyRoles lists the theaters the logged-in user works at and what they
+// are allowed to do there
+func GetMyRoles(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{
+			"message": "Unauthorized",
+		})
+	}
+
+	var assignments []models.RoleAssignment
+	if err := database.DB.Where("user_id = ?", userID).Order("theater_id, role").Find(&assignments).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to fetch roles",
+			"error":   err.Error(),
+		})
+	}
+
+	roles := make([]fiber.Map, len(assignments))
+	for i, assignment := range assignments {
+		roles[i] = fiber.Map{
+			"theater_id":  assignment.TheaterID,
+			"role":        assignment.Role,
+			"permissions": models.RolePermissions[assignment.Role],
+		}
+	}
+
+	return c.JSON(roles)
+}
+
+// GrantTheaterRole gives a user of the chain a role at a theater
+func GrantTheaterRole(c *fiber.Ctx) error {
+	var theater models.Theater
+	if err := database.DB.Scopes(inChain(c)).First(&theater, c.Params("id")).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"message": "Theater not found",
+		})
+	}
+
+	var data map[string]interface{}
+	if err := c.BodyParser(&data); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Invalid request body",
+		})
+	}
+
+	role, _ := data["role"].(string)
+	if _, ok := models.RolePermissions[role]; !ok {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "role must be manager, cashier or usher",
+		})
+	}
+
+	if data["user_id"] == nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "user_id is required",
+		})
+	}
+
+	var user models.User
+	if err := database.DB.Scopes(inChain(c)).First(&user, data["user_id"]).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"message": "User not found",
+		})
+	}
+
+	var existing int64
+	database.DB.Model(&models.RoleAssignment{}).
+		Where("user_id = ? AND theater_id = ? AND role = ?", user.Id, theater.ID, role).
+		Count(&existing)
+	if existing > 0 {
+		return c.Status(409).JSON(fiber.Map{
+			"message": "The user already has this role at this theater",
+		})
+	}
+
+	grantedBy, _ := currentUserID(c)
+	assignment := models.RoleAssignment{
+		UserID:    user.Id,
+		TheaterID: theater.ID,
+		Role:      role,
+		GrantedBy: grantedBy,
+	}
+
+	tx := database.DB.Begin()
+
+	if err := tx.Create(&assignment).Error; err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to grant role",
+			"error":   err.Error(),
+		})
+	}
+
+	if err := recordAudit(tx, c, "create", "role", assignment.ID, nil, assignment); err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to grant role",
+			"error":   err.Error(),
+		})
+	}
+
+	tx.Commit()
+
+	return c.Status(201).JSON(fiber.Map{
+		"message":    "Role granted successfully",
+		"assignment": assignment,
+	})
+}
+
+// RevokeTheaterRole removes a role assignment from a theater
+func RevokeTheaterRole(c *fiber.Ctx) error {
+	var assignment models.RoleAssignment
+	if err := database.DB.
+		Scopes(ownedThrough(c, "theater_id", chainTheaters)).
+		Where("theater_id = ?", c.Params("id")).
+		First(&assignment, c.Params("roleId")).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"message": "Role assignment not found",
+		})
+	}
+
+	tx := database.DB.Begin()
+
+	if err := tx.Delete(&assignment).Error; err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to revoke role",
+			"error":   err.Error(),
+		})
+	}
+
+	if err := recordAudit(tx, c, "delete", "role", assignment.ID, assignment, nil); err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to revoke role",
+			"error":   err.Error(),
+		})
+	}
+
+	tx.Commit()
+
+	return c.JSON(fiber.Map{
+		"message": "Role revoked successfully",
+	})
+}
@@ -0,0 +1,165 @@
+package controller
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/SaharKhamseh/cinema-backend/database"
+	"github.com/SaharKhamseh/cinema-backend/models"
+	"github.com/gofiber/fiber/v2"
+)
+
+// parseClock reads an HH:MM time of day as minutes after midnight
+func parseClock(value string) (int, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("opening hours must use HH:MM")
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// parseOpeningHours reads a list of {"weekday", "opens", "closes"} objects.
+// An empty list removes the opening hours.
+func parseOpeningHours(value interface{}) ([]models.OpeningHours, error) {
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("opening_hours must be a list")
+	}
+
+	hours := []models.OpeningHours{}
+	for _, item := range items {
+		entry, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("opening_hours must be a list")
+		}
+
+		weekday, ok := entry["weekday"].(float64)
+		if !ok || weekday < 0 || weekday > 6 {
+			return nil, fmt.Errorf("weekday must be between 0 (Sunday) and 6 (Saturday)")
+		}
+		opens, _ := entry["opens"].(string)
+		closes, _ := entry["closes"].(string)
+		if _, err := parseClock(opens); err != nil {
+			return nil, err
+		}
+		if _, err := parseClock(closes); err != nil {
+			return nil, err
+		}
+
+		hours = append(hours, models.OpeningHours{Weekday: int(weekday), Opens: opens, Closes: closes})
+	}
+	return hours, nil
+}
+
+// withinOpeningHours reports whether a show from start to end fits in one
+// opening window. A window that closes past midnight counts for the
+// weekday it opened on, so the previous day's window is checked too.
+func withinOpeningHours(hours []models.OpeningHours, start, end time.Time) bool {
+	if len(hours) == 0 {
+		return true
+	}
+
+	for _, day := range []time.Time{start.AddDate(0, 0, -1), start} {
+		midnight := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, day.Location())
+		for _, window := range hours {
+			if window.Weekday != int(day.Weekday()) {
+				continue
+			}
+			opens, _ := parseClock(window.Opens)
+			closes, _ := parseClock(window.Closes)
+			if closes <= opens {
+				closes += 24 * 60
+			}
+			from := midnight.Add(time.Duration(opens) * time.Minute)
+			to := midnight.Add(time.Duration(closes) * time.Minute)
+			if !start.Before(from) && !end.After(to) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// applyScreenTimings sets cleaning_minutes, turnaround_minutes and
+// pre_roll_minutes present in a request body
+func applyScreenTimings(screen *models.Screen, data map[string]interface{}) error {
+	for field, target := range map[string]*int{
+		"cleaning_minutes":   &screen.CleaningMinutes,
+		"turnaround_minutes": &screen.TurnaroundMinutes,
+		"pre_roll_minutes":   &screen.PreRollMinutes,
+	} {
+		if data[field] == nil {
+			continue
+		}
+		minutes, ok := data[field].(float64)
+		if !ok || minutes < 0 || minutes > 240 {
+			return fmt.Errorf("%s must be between 0 and 240", field)
+		}
+		*target = int(minutes)
+	}
+	return nil
+}
+
+// showEndTime is when a show starting at start ends: the pre-roll of ads
+// and trailers runs before the movie
+func showEndTime(start time.Time, preRollMinutes, duration int) time.Time {
+	return start.Add(time.Minute * time.Duration(preRollMinutes+duration))
+}
+
+// bufferedSlot widens a show slot by the screen's cleaning and turnaround
+// minutes on both sides. No other show on the screen may overlap it.
+func bufferedSlot(screen models.Screen, start, end time.Time) (time.Time, time.Time) {
+	buffer := time.Duration(screen.CleaningMinutes+screen.TurnaroundMinutes) * time.Minute
+	return start.Add(-buffer), end.Add(buffer)
+}
+
+// scheduleError is a slot that breaks a scheduling rule, as opposed to a
+// database failure while checking it
+type scheduleError string
+
+func (e scheduleError) Error() string {
+	return string(e)
+}
+
+// checkSchedule validates a show slot on a screen: it has to fit in the
+// theater's opening hours and keep the screen's cleaning and turnaround
+// buffer to every other show. ignoreID skips the show being moved.
+func checkSchedule(screen models.Screen, start, end time.Time, ignoreID uint) error {
+	var theater models.Theater
+	if err := database.DB.Unscoped().First(&theater, screen.TheaterID).Error; err != nil {
+		return err
+	}
+
+	if !withinOpeningHours(theater.OpeningHours, start, end) {
+		return scheduleError("Show time is outside the theater's opening hours")
+	}
+
+	from, to := bufferedSlot(screen, start, end)
+
+	var conflicting int64
+	if err := database.DB.Model(&models.ShowTime{}).
+		Where("screen_id = ? AND id <> ?", screen.ID, ignoreID).
+		Where("start_time < ? AND end_time > ?", to, from).
+		Count(&conflicting).Error; err != nil {
+		return err
+	}
+	if conflicting > 0 {
+		return scheduleError("Time slot conflicts with existing show")
+	}
+
+	return nil
+}
+
+// scheduleErrorResponse answers 400 for a broken scheduling rule and 500
+// when the check itself failed
+func scheduleErrorResponse(c *fiber.Ctx, err error) error {
+	if _, ok := err.(scheduleError); ok {
+		return c.Status(400).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+	return c.Status(500).JSON(fiber.Map{
+		"message": "Failed to check the schedule",
+		"error":   err.Error(),
+	})
+}
@@ -0,0 +1,152 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/SaharKhamseh/cinema-backend/models"
+)
+
+func TestShowEndTime(t *testing.T) {
+	start := time.Date(2025, 6, 6, 20, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		preRoll, duration int
+		want              string
+	}{
+		{0, 120, "22:00"},
+		{15, 120, "22:15"},
+		{20, 250, "00:30"},
+	}
+
+	for _, tt := range tests {
+		if got := showEndTime(start, tt.preRoll, tt.duration).Format("15:04"); got != tt.want {
+			t.Errorf("showEndTime(20:00, %d, %d) = %s, want %s", tt.preRoll, tt.duration, got, tt.want)
+		}
+	}
+}
+
+func TestBufferedSlot(t *testing.T) {
+	start := time.Date(2025, 6, 6, 20, 0, 0, 0, time.UTC)
+	end := start.Add(2 * time.Hour)
+
+	tests := []struct {
+		name                 string
+		cleaning, turnaround int
+		from, to             string
+	}{
+		{"no buffer", 0, 0, "20:00", "22:00"},
+		{"cleaning only", 15, 0, "19:45", "22:15"},
+		{"cleaning and turnaround", 15, 10, "19:35", "22:25"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			screen := models.Screen{CleaningMinutes: tt.cleaning, TurnaroundMinutes: tt.turnaround}
+			from, to := bufferedSlot(screen, start, end)
+			if from.Format("15:04") != tt.from || to.Format("15:04") != tt.to {
+				t.Errorf("bufferedSlot = %s-%s, want %s-%s", from.Format("15:04"), to.Format("15:04"), tt.from, tt.to)
+			}
+		})
+	}
+}
+
+func TestWithinOpeningHours(t *testing.T) {
+	// 2025-06-06 is a Friday (5) and 2025-06-07 a Saturday (6)
+	hours := []models.OpeningHours{
+		{Weekday: 5, Opens: "10:00", Closes: "02:00"},
+		{Weekday: 6, Opens: "12:00", Closes: "23:00"},
+	}
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2025, 6, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name       string
+		hours      []models.OpeningHours
+		start, end time.Time
+		want       bool
+	}{
+		{"no opening hours", nil, at(6, 3, 0), at(6, 5, 0), true},
+		{"inside the window", hours, at(6, 18, 0), at(6, 20, 0), true},
+		{"starts before opening", hours, at(6, 9, 30), at(6, 11, 30), false},
+		{"runs past midnight in a late window", hours, at(6, 23, 30), at(7, 1, 30), true},
+		{"ends after a late closing", hours, at(6, 23, 30), at(7, 2, 30), false},
+		{"after midnight counts for the day before", hours, at(7, 0, 30), at(7, 1, 45), true},
+		{"next day window", hours, at(7, 12, 0), at(7, 14, 0), true},
+		{"closed weekday", hours, at(8, 12, 0), at(8, 14, 0), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := withinOpeningHours(tt.hours, tt.start, tt.end); got != tt.want {
+				t.Errorf("withinOpeningHours = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseOpeningHours(t *testing.T) {
+	entry := func(weekday interface{}, opens, closes string) map[string]interface{} {
+		return map[string]interface{}{"weekday": weekday, "opens": opens, "closes": closes}
+	}
+
+	tests := []struct {
+		name    string
+		value   interface{}
+		wantErr bool
+	}{
+		{"valid", []interface{}{entry(1.0, "10:00", "23:30")}, false},
+		{"empty list clears", []interface{}{}, false},
+		{"not a list", "10:00-23:00", true},
+		{"weekday out of range", []interface{}{entry(7.0, "10:00", "23:00")}, true},
+		{"missing weekday", []interface{}{entry(nil, "10:00", "23:00")}, true},
+		{"bad clock", []interface{}{entry(1.0, "10am", "23:00")}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseOpeningHours(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestApplyScreenTimings(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    map[string]interface{}
+		want    models.Screen
+		wantErr bool
+	}{
+		{"all fields", map[string]interface{}{"cleaning_minutes": 15.0, "turnaround_minutes": 5.0, "pre_roll_minutes": 20.0},
+			models.Screen{CleaningMinutes: 15, TurnaroundMinutes: 5, PreRollMinutes: 20}, false},
+		{"missing fields are kept", map[string]interface{}{"cleaning_minutes": 0.0},
+			models.Screen{TurnaroundMinutes: 10, PreRollMinutes: 10}, false},
+		{"negative", map[string]interface{}{"pre_roll_minutes": -1.0}, models.Screen{}, true},
+		{"too long", map[string]interface{}{"cleaning_minutes": 241.0}, models.Screen{}, true},
+		{"not a number", map[string]interface{}{"turnaround_minutes": "10"}, models.Screen{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			screen := models.Screen{CleaningMinutes: 10, TurnaroundMinutes: 10, PreRollMinutes: 10}
+			err := applyScreenTimings(&screen, tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if screen.CleaningMinutes != tt.want.CleaningMinutes ||
+				screen.TurnaroundMinutes != tt.want.TurnaroundMinutes ||
+				screen.PreRollMinutes != tt.want.PreRollMinutes {
+				t.Errorf("timings = %d/%d/%d, want %d/%d/%d",
+					screen.CleaningMinutes, screen.TurnaroundMinutes, screen.PreRollMinutes,
+					tt.want.CleaningMinutes, tt.want.TurnaroundMinutes, tt.want.PreRollMinutes)
+			}
+		})
+	}
+}
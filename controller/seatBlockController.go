@@ -0,0 +1,367 @@
+package controller
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/SaharKhamseh/cinema-backend/database"
+	"github.com/SaharKhamseh/cinema-backend/models"
+	"github.com/gofiber/fiber/v2"
+)
+
+var seatBlockReasons = map[string]bool{
+	"maintenance": true,
+	"house":       true,
+	"vip":         true,
+	"press":       true,
+}
+
+// blockedSeats returns the unreleased blocks covering a show by seat id
+func blockedSeats(showTime models.ShowTime) (map[uint]models.SeatBlock, error) {
+	var blocks []models.SeatBlock
+	if err := database.DB.
+		Preload("Seats").
+		Where("screen_id = ? AND released_at IS NULL", showTime.ScreenID).
+		Order("id").
+		Find(&blocks).Error; err != nil {
+		return nil, err
+	}
+
+	blocked := map[uint]models.SeatBlock{}
+	for _, block := range blocks {
+		if !blockApplies(block, showTime) {
+			continue
+		}
+		for _, seat := range block.Seats {
+			if _, ok := blocked[seat.ID]; !ok {
+				blocked[seat.ID] = block
+			}
+		}
+	}
+	return blocked, nil
+}
+
+// blockApplies reports whether an unreleased block of the show's screen keeps
+// its seats off sale for the show, either because it is tied to the show or
+// because its time window overlaps it
+func blockApplies(block models.SeatBlock, showTime models.ShowTime) bool {
+	if block.ShowTimeID != nil && *block.ShowTimeID != showTime.ID {
+		return false
+	}
+	if block.StartsAt != nil && !block.StartsAt.Before(showTime.EndTime) {
+		return false
+	}
+	if block.EndsAt != nil && !block.EndsAt.After(showTime.StartTime) {
+		return false
+	}
+	return true
+}
+
+// blockSeatSelection reads the seats of a block from a request body, either
+// "seat_ids" or a "row" with the seat numbers "from" and "to"
+func blockSeatSelection(screenID uint, data map[string]interface{}) ([]models.Seat, error) {
+	var seats []models.Seat
+
+	if data["seat_ids"] != nil {
+		ids, err := idList(data["seat_ids"])
+		if err != nil {
+			return nil, fmt.Errorf("seat_ids: %w", err)
+		}
+		ids = uniqueIDs(ids)
+		if len(ids) == 0 {
+			return nil, fmt.Errorf("seat_ids cannot be empty")
+		}
+		if err := database.DB.Where("id IN ? AND screen_id = ?", ids, screenID).Find(&seats).Error; err != nil {
+			return nil, err
+		}
+		if len(seats) != len(ids) {
+			return nil, fmt.Errorf("one or more seats do not exist on this screen")
+		}
+		return seats, nil
+	}
+
+	row, _ := data["row"].(string)
+	from, fromOK := data["from"].(float64)
+	to, toOK := data["to"].(float64)
+	if row = strings.ToUpper(strings.TrimSpace(row)); row == "" || !fromOK || !toOK || from < 1 || to < from {
+		return nil, fmt.Errorf("give seat_ids, or a row with the seat numbers from and to")
+	}
+
+	if err := database.DB.
+		Where("screen_id = ? AND `row` = ? AND number BETWEEN ? AND ?", screenID, row, int(from), int(to)).
+		Order("number").
+		Find(&seats).Error; err != nil {
+		return nil, err
+	}
+	if len(seats) == 0 {
+		return nil, fmt.Errorf("no seats in this range")
+	}
+	return seats, nil
+}
+
+// CreateSeatBlock takes seats of a screen off sale. It answers with the
+// number of bookings that already hold a blocked seat, so staff can move
+// those customers. The optional starts_at and ends_at dates (YYYY-MM-DD,
+// ends_at inclusive) limit the block to a period.
+func CreateSeatBlock(c *fiber.Ctx) error {
+	var screen models.Screen
+	if err := database.DB.Scopes(screensInChain(c)).First(&screen, c.Params("id")).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"message": "Screen not found",
+		})
+	}
+
+	var data map[string]interface{}
+	if err := c.BodyParser(&data); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Invalid request body",
+		})
+	}
+
+	reason, _ := data["reason"].(string)
+	if !seatBlockReasons[reason] {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "reason must be maintenance, house, vip or press",
+		})
+	}
+	note, _ := data["note"].(string)
+
+	seats, err := blockSeatSelection(screen.ID, data)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	blockedBy, _ := currentUserID(c)
+	block := models.SeatBlock{
+		ScreenID:  screen.ID,
+		Seats:     seats,
+		Reason:    reason,
+		Note:      strings.TrimSpace(note),
+		BlockedBy: blockedBy,
+	}
+
+	if data["show_time_id"] != nil {
+		var showTime models.ShowTime
+		if err := database.DB.Where("screen_id = ?", screen.ID).First(&showTime, data["show_time_id"]).Error; err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"message": "show_time_id must be a show on this screen",
+			})
+		}
+		block.ShowTimeID = &showTime.ID
+	}
+
+	for field, target := range map[string]**time.Time{"starts_at": &block.StartsAt, "ends_at": &block.EndsAt} {
+		value, _ := data[field].(string)
+		if value == "" {
+			continue
+		}
+		day, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"message": fmt.Sprintf("invalid %s date format. Use YYYY-MM-DD", field),
+			})
+		}
+		// The ends_at date is inclusive
+		if field == "ends_at" {
+			day = day.Add(24 * time.Hour)
+		}
+		*target = &day
+	}
+	if block.StartsAt != nil && block.EndsAt != nil && !block.EndsAt.After(*block.StartsAt) {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "ends_at must not be before starts_at",
+		})
+	}
+
+	seatIDs := make([]uint, len(seats))
+	for i, seat := range seats {
+		seatIDs[i] = seat.ID
+	}
+
+	// Bookings for the shows the block covers that already hold its seats
+	var conflicts int64
+	conflictQuery := database.DB.Model(&models.Booking{}).
+		Joins("JOIN booking_seats ON bookings.id = booking_seats.booking_id").
+		Joins("JOIN show_times ON show_times.id = bookings.show_time_id").
+		Where("booking_seats.seat_id IN ? AND bookings.status != ?", seatIDs, "cancelled").
+		Where("show_times.end_time > ?", time.Now())
+	if block.ShowTimeID != nil {
+		conflictQuery = conflictQuery.Where("show_times.id = ?", *block.ShowTimeID)
+	}
+	if block.StartsAt != nil {
+		conflictQuery = conflictQuery.Where("show_times.end_time > ?", *block.StartsAt)
+	}
+	if block.EndsAt != nil {
+		conflictQuery = conflictQuery.Where("show_times.start_time < ?", *block.EndsAt)
+	}
+	conflictQuery.Distinct("bookings.id").Count(&conflicts)
+
+	tx := database.DB.Begin()
+
+	if err := tx.Create(&block).Error; err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to block seats",
+			"error":   err.Error(),
+		})
+	}
+
+	if err := recordAudit(tx, c, "create", "seat_block", block.ID, nil, block); err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to block seats",
+			"error":   err.Error(),
+		})
+	}
+
+	tx.Commit()
+
+	return c.Status(201).JSON(fiber.Map{
+		"message":          "Seats blocked successfully",
+		"block":            block,
+		"booked_conflicts": conflicts,
+	})
+}
+
+// GetSeatBlocks lists the unreleased blocks of a screen, or every block
+// with ?all=true
+func GetSeatBlocks(c *fiber.Ctx) error {
+	var screen models.Screen
+	if err := database.DB.Scopes(screensInChain(c)).First(&screen, c.Params("id")).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"message": "Screen not found",
+		})
+	}
+
+	query := database.DB.Model(&models.SeatBlock{}).Where("screen_id = ?", screen.ID)
+	if !c.QueryBool("all") {
+		query = query.Where("released_at IS NULL")
+	}
+
+	var blocks []models.SeatBlock
+	sortFields := map[string]string{"created_at": "created_at", "id": "id"}
+	meta, err := paginateOffset(c, query, &blocks, sortFields, "created_at DESC", preload("Seats"))
+	if err != nil {
+		return pageErrorResponse(c, err, "Failed to fetch seat blocks")
+	}
+
+	return c.JSON(pageEnvelope(blocks, meta))
+}
+
+// ReleaseSeatBlock puts the seats of a block back on sale
+func ReleaseSeatBlock(c *fiber.Ctx) error {
+	var block models.SeatBlock
+	if err := database.DB.
+		Scopes(ownedThrough(c, "screen_id", chainScreens)).
+		Preload("Seats").
+		First(&block, c.Params("id")).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"message": "Seat block not found",
+		})
+	}
+
+	if block.ReleasedAt != nil {
+		return c.Status(409).JSON(fiber.Map{
+			"message": "Seat block was already released",
+		})
+	}
+	before := block
+
+	releasedBy, _ := currentUserID(c)
+	now := time.Now()
+	block.ReleasedAt = &now
+	block.ReleasedBy = &releasedBy
+
+	tx := database.DB.Begin()
+
+	if err := tx.Model(&block).Updates(map[string]interface{}{
+		"released_at": block.ReleasedAt,
+		"released_by": block.ReleasedBy,
+	}).Error; err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to release seat block",
+			"error":   err.Error(),
+		})
+	}
+
+	if err := recordAudit(tx, c, "update", "seat_block", block.ID, before, block); err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to release seat block",
+			"error":   err.Error(),
+		})
+	}
+
+	tx.Commit()
+
+	return c.JSON(fiber.Map{
+		"message": "Seat block released successfully",
+		"block":   block,
+	})
+}
+
+// ShowTimeSeat is a seat on the seat map of a show
+type ShowTimeSeat struct {
+	models.Seat
+	Status      string `json:"status"` // available, booked or blocked
+	BlockReason string `json:"block_reason,omitempty"`
+}
+
+// GetShowTimeSeats returns the seat map of a show with the status of
+// every seat
+func GetShowTimeSeats(c *fiber.Ctx) error {
+	var showTime models.ShowTime
+	if err := database.DB.Scopes(showTimesInChain(c)).First(&showTime, c.Params("id")).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"message": "Show time not found",
+		})
+	}
+
+	var seats []models.Seat
+	if err := database.DB.Where("screen_id = ?", showTime.ScreenID).Order("y, `row`, x, number").Find(&seats).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to fetch seats",
+			"error":   err.Error(),
+		})
+	}
+
+	var booked []uint
+	if err := database.DB.Model(&models.Booking{}).
+		Joins("JOIN booking_seats ON bookings.id = booking_seats.booking_id").
+		Where("bookings.show_time_id = ? AND bookings.status != ?", showTime.ID, "cancelled").
+		Pluck("booking_seats.seat_id", &booked).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to fetch seats",
+			"error":   err.Error(),
+		})
+	}
+	bookedSet := map[uint]bool{}
+	for _, id := range booked {
+		bookedSet[id] = true
+	}
+
+	blocked, err := blockedSeats(showTime)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to fetch seats",
+			"error":   err.Error(),
+		})
+	}
+
+	result := make([]ShowTimeSeat, len(seats))
+	for i, seat := range seats {
+		result[i] = ShowTimeSeat{Seat: seat, Status: "available"}
+		if bookedSet[seat.ID] {
+			result[i].Status = "booked"
+		} else if block, ok := blocked[seat.ID]; ok {
+			result[i].Status = "blocked"
+			result[i].BlockReason = block.Reason
+		}
+	}
+
+	return c.JSON(result)
+}
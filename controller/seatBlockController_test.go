@@ -0,0 +1,44 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/SaharKhamseh/cinema-backend/models"
+)
+
+func TestBlockApplies(t *testing.T) {
+	show := models.ShowTime{
+		ID:        5,
+		StartTime: time.Date(2025, 4, 10, 20, 0, 0, 0, time.UTC),
+		EndTime:   time.Date(2025, 4, 10, 22, 0, 0, 0, time.UTC),
+	}
+	day := func(d int) *time.Time {
+		t := time.Date(2025, 4, d, 0, 0, 0, 0, time.UTC)
+		return &t
+	}
+	showID := func(id uint) *uint { return &id }
+
+	tests := []struct {
+		name  string
+		block models.SeatBlock
+		want  bool
+	}{
+		{"open ended", models.SeatBlock{}, true},
+		{"for this show", models.SeatBlock{ShowTimeID: showID(5)}, true},
+		{"for another show", models.SeatBlock{ShowTimeID: showID(6)}, false},
+		{"period covering the show", models.SeatBlock{StartsAt: day(10), EndsAt: day(11)}, true},
+		{"period ended before the show", models.SeatBlock{StartsAt: day(8), EndsAt: day(10)}, false},
+		{"period starting after the show", models.SeatBlock{StartsAt: day(11)}, false},
+		{"ends during the show", models.SeatBlock{EndsAt: &show.EndTime}, true},
+		{"starts as the show ends", models.SeatBlock{StartsAt: &show.EndTime}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := blockApplies(tt.block, show); got != tt.want {
+				t.Errorf("blockApplies = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,617 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/SaharKhamseh/cinema-backend/database"
+	"github.com/SaharKhamseh/cinema-backend/models"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// SeatLayout describes the seats of a screen row by row, front to back.
+//
+// A row either lists its seats, which allows curved rows and arbitrary
+// numbering through explicit coordinates:
+//
+//	{"label": "A", "seats": [{"number": 1, "x": 0, "y": 0.2}, {"number": 2, "x": 1}]}
+//
+// or gives a grid spec with one character per column: s standard,
+// p premium, v vip, w wheelchair space, c companion seat of the adjacent
+// wheelchair space, e easy access, "_" an empty gap and "|" an aisle.
+// Seats are numbered left to right from start_number, skipping gaps and
+// aisles:
+//
+//	{"label": "B", "spec": "wc|pppp|ss"}
+//
+// Accessibility lists any of wheelchair, companion, easy_access and
+// hearing_loop, for a whole row or a single seat. An explicit companion
+// seat names its wheelchair space in the same row with companion_of.
+type SeatLayout struct {
+	Rows []LayoutRow `json:"rows"`
+}
+
+// LayoutRow is one row of a seat layout. Y defaults to the row's position
+// in the layout and Category to standard.
+type LayoutRow struct {
+	Label         string       `json:"label"`
+	Y             *float64     `json:"y,omitempty"`
+	Category      string       `json:"category,omitempty"`
+	Accessibility []string     `json:"accessibility,omitempty"`
+	Spec          string       `json:"spec,omitempty"`
+	StartNumber   int          `json:"start_number,omitempty"`
+	Seats         []LayoutSeat `json:"seats,omitempty"`
+}
+
+// LayoutSeat is one seat of an explicit row. Y defaults to the row's Y.
+type LayoutSeat struct {
+	Number        int      `json:"number"`
+	X             float64  `json:"x"`
+	Y             *float64 `json:"y,omitempty"`
+	Category      string   `json:"category,omitempty"`
+	Accessibility []string `json:"accessibility,omitempty"`
+	CompanionOf   int      `json:"companion_of,omitempty"`
+}
+
+// seatCategories are the categories a layout may use
+var seatCategories = map[string]bool{
+	"standard": true,
+	"premium":  true,
+	"vip":      true,
+}
+
+// specCategories maps grid spec characters to seat categories
+var specCategories = map[rune]string{
+	's': "standard",
+	'p': "premium",
+	'v': "vip",
+	'w': "standard",
+	'c': "standard",
+	'e': "standard",
+}
+
+// specAccessibility maps grid spec characters to accessibility features
+var specAccessibility = map[rune]string{
+	'w': "wheelchair",
+	'c': "companion",
+	'e': "easy_access",
+}
+
+// setAccessibility turns on the named accessibility features of a seat
+func setAccessibility(seat *models.Seat, features []string) error {
+	for _, feature := range features {
+		switch feature {
+		case "wheelchair":
+			seat.Wheelchair = true
+		case "companion":
+			seat.Companion = true
+		case "easy_access":
+			seat.EasyAccess = true
+		case "hearing_loop":
+			seat.HearingLoop = true
+		default:
+			return fmt.Errorf("row %s: unknown accessibility feature %q", seat.Row, feature)
+		}
+	}
+	if seat.Wheelchair && seat.Companion {
+		return fmt.Errorf("row %s: seat %d cannot be both a wheelchair space and a companion seat", seat.Row, seat.Number)
+	}
+	return nil
+}
+
+// plannedSeat is a seat generated from a layout before it is saved.
+// companionOf is the number of the wheelchair space in the same row.
+type plannedSeat struct {
+	models.Seat
+	companionOf int
+}
+
+// layoutConflictError means applying a layout would remove seats that are
+// still booked for upcoming shows
+type layoutConflictError struct {
+	Seats []string
+}
+
+func (e *layoutConflictError) Error() string {
+	return "seats with bookings for upcoming shows cannot be removed: " + strings.Join(e.Seats, ", ")
+}
+
+// parseSeatLayout reads a layout from a request body value. It is either a
+// layout object or a grid text with one "LABEL: spec" line per row; rows
+// without a label are lettered A, B, C... in order.
+func parseSeatLayout(value interface{}) (SeatLayout, error) {
+	var layout SeatLayout
+
+	if text, ok := value.(string); ok {
+		for _, line := range strings.Split(text, "\n") {
+			line = strings.TrimRight(line, "\r")
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			row := LayoutRow{Spec: line}
+			if i := strings.Index(line, ":"); i >= 0 {
+				row.Label = strings.TrimSpace(line[:i])
+				row.Spec = strings.TrimSpace(line[i+1:])
+			}
+			layout.Rows = append(layout.Rows, row)
+		}
+		return layout, nil
+	}
+
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return layout, fmt.Errorf("invalid layout")
+	}
+	if err := json.Unmarshal(raw, &layout); err != nil {
+		return layout, fmt.Errorf("invalid layout: %v", err)
+	}
+	return layout, nil
+}
+
+// defaultSeatLayout is the layout used when a screen is created with a
+// capacity only: up to eight rows A-H of equal length, with the seats that
+// do not divide evenly added to the back rows.
+func defaultSeatLayout(capacity int) SeatLayout {
+	var layout SeatLayout
+	if capacity <= 0 {
+		return layout
+	}
+
+	rows := 8
+	if capacity < rows {
+		rows = capacity
+	}
+	perRow, extra := capacity/rows, capacity%rows
+
+	for i := 0; i < rows; i++ {
+		length := perRow
+		if i >= rows-extra {
+			length++
+		}
+		layout.Rows = append(layout.Rows, LayoutRow{
+			Label: string(rune('A' + i)),
+			Spec:  strings.Repeat("s", length),
+		})
+	}
+	return layout
+}
+
+// buildSeats validates a layout and expands it into seats
+func buildSeats(layout SeatLayout) ([]plannedSeat, error) {
+	if len(layout.Rows) == 0 {
+		return nil, fmt.Errorf("layout needs at least one row")
+	}
+
+	var seats []plannedSeat
+	labels := map[string]bool{}
+
+	for i, row := range layout.Rows {
+		if row.Label == "" {
+			if i >= 26 {
+				return nil, fmt.Errorf("row %d needs a label", i+1)
+			}
+			row.Label = string(rune('A' + i))
+		}
+		if labels[row.Label] {
+			return nil, fmt.Errorf("row %s appears twice", row.Label)
+		}
+		labels[row.Label] = true
+
+		y := float64(i)
+		if row.Y != nil {
+			y = *row.Y
+		}
+		if row.Category == "" {
+			row.Category = "standard"
+		}
+		if !seatCategories[row.Category] {
+			return nil, fmt.Errorf("row %s: unknown category %q", row.Label, row.Category)
+		}
+
+		if (row.Spec == "") == (len(row.Seats) == 0) {
+			return nil, fmt.Errorf("row %s needs either spec or seats", row.Label)
+		}
+
+		first := len(seats)
+		numbers := map[int]bool{}
+		add := func(seat plannedSeat, features []string) error {
+			if seat.Number <= 0 {
+				return fmt.Errorf("row %s: seat numbers must be positive", row.Label)
+			}
+			if numbers[seat.Number] {
+				return fmt.Errorf("row %s: seat %d appears twice", row.Label, seat.Number)
+			}
+			if !seatCategories[seat.Category] {
+				return fmt.Errorf("row %s: unknown category %q", row.Label, seat.Category)
+			}
+			if err := setAccessibility(&seat.Seat, append(append([]string{}, row.Accessibility...), features...)); err != nil {
+				return err
+			}
+			numbers[seat.Number] = true
+			seats = append(seats, seat)
+			return nil
+		}
+
+		if row.Spec != "" {
+			number := row.StartNumber
+			if number == 0 {
+				number = 1
+			}
+
+			// Companion seats belong to the wheelchair space next to them
+			columns := map[int]int{}
+			chars := []rune(row.Spec)
+			for x, char := range chars {
+				if char == '_' || char == '|' || char == ' ' {
+					continue
+				}
+				category, ok := specCategories[char]
+				if !ok {
+					return nil, fmt.Errorf("row %s: unknown spec character %q", row.Label, char)
+				}
+				var features []string
+				if feature, ok := specAccessibility[char]; ok {
+					features = append(features, feature)
+				}
+				seat := plannedSeat{Seat: models.Seat{Row: row.Label, Number: number, Category: category, X: float64(x), Y: y}}
+				if err := add(seat, features); err != nil {
+					return nil, err
+				}
+				columns[x] = number
+				number++
+			}
+
+			for x, char := range chars {
+				if char != 'c' {
+					continue
+				}
+				switch {
+				case x > 0 && chars[x-1] == 'w':
+					seats[first+indexOfNumber(seats[first:], columns[x])].companionOf = columns[x-1]
+				case x+1 < len(chars) && chars[x+1] == 'w':
+					seats[first+indexOfNumber(seats[first:], columns[x])].companionOf = columns[x+1]
+				}
+			}
+		} else {
+			for _, layoutSeat := range row.Seats {
+				seat := plannedSeat{
+					Seat: models.Seat{
+						Row:      row.Label,
+						Number:   layoutSeat.Number,
+						Category: layoutSeat.Category,
+						X:        layoutSeat.X,
+						Y:        y,
+					},
+					companionOf: layoutSeat.CompanionOf,
+				}
+				if seat.Category == "" {
+					seat.Category = row.Category
+				}
+				if layoutSeat.Y != nil {
+					seat.Y = *layoutSeat.Y
+				}
+				if err := add(seat, layoutSeat.Accessibility); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		// Every companion seat needs a wheelchair space in its row
+		for k := first; k < len(seats); k++ {
+			seat := &seats[k]
+			if seat.companionOf != 0 && !seat.Companion {
+				seat.Companion = true
+			}
+			if !seat.Companion {
+				continue
+			}
+			target := indexOfNumber(seats[first:], seat.companionOf)
+			if seat.companionOf == 0 || target < 0 || !seats[first+target].Wheelchair {
+				return nil, fmt.Errorf("row %s: companion seat %d needs a wheelchair space next to it", row.Label, seat.Number)
+			}
+		}
+	}
+
+	if len(seats) == 0 {
+		return nil, fmt.Errorf("layout has no seats")
+	}
+	return seats, nil
+}
+
+// indexOfNumber finds the seat with a number, or -1
+func indexOfNumber(seats []plannedSeat, number int) int {
+	for i, seat := range seats {
+		if seat.Number == number {
+			return i
+		}
+	}
+	return -1
+}
+
+// applySeatLayout makes the screen's seats match the layout. Seats are
+// matched by row and number so their ids, and the bookings that point at
+// them, survive a redesign; removed seats are soft-deleted and refused
+// while they are booked for an upcoming show. Capacity becomes the seat
+// count, which has to fit in the theater's licensed capacity.
+func applySeatLayout(tx *gorm.DB, screen *models.Screen, layout SeatLayout) error {
+	seats, err := buildSeats(layout)
+	if err != nil {
+		return err
+	}
+
+	if err := checkLicensedCapacity(tx, *screen, len(seats)); err != nil {
+		return err
+	}
+
+	var existing []models.Seat
+	if err := tx.Unscoped().Where("screen_id = ?", screen.ID).Find(&existing).Error; err != nil {
+		return err
+	}
+
+	byPosition := map[string]models.Seat{}
+	for _, seat := range existing {
+		byPosition[fmt.Sprintf("%s-%d", seat.Row, seat.Number)] = seat
+	}
+
+	saved := map[string]uint{}
+	for _, planned := range seats {
+		seat := planned.Seat
+		key := fmt.Sprintf("%s-%d", seat.Row, seat.Number)
+		seat.ScreenID = screen.ID
+		if old, ok := byPosition[key]; ok {
+			seat.ID = old.ID
+			delete(byPosition, key)
+		}
+		if err := tx.Unscoped().Save(&seat).Error; err != nil {
+			return err
+		}
+		saved[key] = seat.ID
+	}
+
+	// Link companion seats once every wheelchair space has an id
+	for _, planned := range seats {
+		if planned.companionOf == 0 {
+			continue
+		}
+		seatID := saved[fmt.Sprintf("%s-%d", planned.Row, planned.Number)]
+		spaceID := saved[fmt.Sprintf("%s-%d", planned.Row, planned.companionOf)]
+		if err := tx.Model(&models.Seat{}).Where("id = ?", seatID).Update("companion_of_id", spaceID).Error; err != nil {
+			return err
+		}
+	}
+
+	var removed []uint
+	var labels []string
+	for _, seat := range byPosition {
+		if !seat.DeletedAt.Valid {
+			removed = append(removed, seat.ID)
+			labels = append(labels, fmt.Sprintf("%s%d", seat.Row, seat.Number))
+		}
+	}
+
+	if len(removed) > 0 {
+		var booked []uint
+		if err := tx.Table("booking_seats").
+			Joins("JOIN bookings ON bookings.id = booking_seats.booking_id").
+			Joins("JOIN show_times ON show_times.id = bookings.show_time_id").
+			Where("booking_seats.seat_id IN ? AND bookings.status != ? AND show_times.start_time > ?",
+				removed, "cancelled", time.Now()).
+			Distinct().
+			Pluck("booking_seats.seat_id", &booked).Error; err != nil {
+			return err
+		}
+		if len(booked) > 0 {
+			blocked := map[uint]bool{}
+			for _, id := range booked {
+				blocked[id] = true
+			}
+			conflict := &layoutConflictError{}
+			for i, id := range removed {
+				if blocked[id] {
+					conflict.Seats = append(conflict.Seats, labels[i])
+				}
+			}
+			return conflict
+		}
+
+		if err := tx.Where("id IN ?", removed).Delete(&models.Seat{}).Error; err != nil {
+			return err
+		}
+	}
+
+	raw, err := json.Marshal(layout)
+	if err != nil {
+		return err
+	}
+	screen.Layout = string(raw)
+	screen.Capacity = len(seats)
+
+	return tx.Model(screen).Updates(map[string]interface{}{
+		"layout":   screen.Layout,
+		"capacity": screen.Capacity,
+	}).Error
+}
+
+// layoutErrorResponse answers 409 when a layout change would remove booked
+// seats or exceed the theater's licensed capacity, and 500 otherwise
+func layoutErrorResponse(c *fiber.Ctx, err error, message string) error {
+	switch err := err.(type) {
+	case *layoutConflictError:
+		return c.Status(409).JSON(fiber.Map{
+			"message": "Seats with bookings for upcoming shows cannot be removed",
+			"seats":   err.Seats,
+		})
+	case *capacityError:
+		return c.Status(409).JSON(fiber.Map{
+			"message":  "The screens would have more seats than the theater is licensed for",
+			"licensed": err.Licensed,
+			"seats":    err.Seats,
+		})
+	}
+	return c.Status(500).JSON(fiber.Map{
+		"message": message,
+		"error":   err.Error(),
+	})
+}
+
+// screenLayout returns the stored layout of a screen, or the default
+// layout for its capacity when it predates layouts
+func screenLayout(screen models.Screen) SeatLayout {
+	var layout SeatLayout
+	if screen.Layout == "" || json.Unmarshal([]byte(screen.Layout), &layout) != nil {
+		return defaultSeatLayout(screen.Capacity)
+	}
+	return layout
+}
+
+// GetScreenLayout returns the seat layout of a screen
+func GetScreenLayout(c *fiber.Ctx) error {
+	var screen models.Screen
+
+	if err := database.DB.Scopes(screensInChain(c)).First(&screen, c.Params("id")).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"message": "Screen not found",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"screen_id": screen.ID,
+		"capacity":  screen.Capacity,
+		"layout":    screenLayout(screen),
+	})
+}
+
+// SetScreenLayout replaces the seat layout of a screen
+func SetScreenLayout(c *fiber.Ctx) error {
+	var screen models.Screen
+
+	if err := database.DB.Scopes(screensInChain(c)).First(&screen, c.Params("id")).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"message": "Screen not found",
+		})
+	}
+
+	var data map[string]interface{}
+	if err := c.BodyParser(&data); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Invalid request body",
+		})
+	}
+
+	if data["layout"] == nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "layout is required",
+		})
+	}
+
+	layout, err := parseSeatLayout(data["layout"])
+	if err == nil {
+		_, err = buildSeats(layout)
+	}
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	before := screen
+
+	tx := database.DB.Begin()
+
+	if err := applySeatLayout(tx, &screen, layout); err != nil {
+		tx.Rollback()
+		return layoutErrorResponse(c, err, "Failed to update layout")
+	}
+
+	if err := recordAudit(tx, c, "update", "screen", screen.ID, before, screen); err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to update layout",
+			"error":   err.Error(),
+		})
+	}
+
+	tx.Commit()
+
+	return c.JSON(fiber.Map{
+		"message":  "Layout updated successfully",
+		"capacity": screen.Capacity,
+		"layout":   layout,
+	})
+}
+
+// syncLayoutFromSeats rewrites a screen's layout and capacity from its
+// current seats, after seats were edited one by one
+func syncLayoutFromSeats(tx *gorm.DB, screen *models.Screen) error {
+	var seats []models.Seat
+	if err := tx.Where("screen_id = ?", screen.ID).Order("y, `row`, x").Find(&seats).Error; err != nil {
+		return err
+	}
+
+	numbers := map[uint]int{}
+	for _, seat := range seats {
+		numbers[seat.ID] = seat.Number
+	}
+
+	var layout SeatLayout
+	rows := map[string]int{}
+	for _, seat := range seats {
+		index, ok := rows[seat.Row]
+		if !ok {
+			index = len(layout.Rows)
+			rows[seat.Row] = index
+			y := seat.Y
+			layout.Rows = append(layout.Rows, LayoutRow{Label: seat.Row, Y: &y})
+		}
+
+		layoutSeat := LayoutSeat{Number: seat.Number, X: seat.X, Category: seat.Category}
+		if seat.Y != *layout.Rows[index].Y {
+			y := seat.Y
+			layoutSeat.Y = &y
+		}
+		for feature, on := range map[string]bool{
+			"wheelchair":   seat.Wheelchair,
+			"companion":    seat.Companion,
+			"easy_access":  seat.EasyAccess,
+			"hearing_loop": seat.HearingLoop,
+		} {
+			if on {
+				layoutSeat.Accessibility = append(layoutSeat.Accessibility, feature)
+			}
+		}
+		sort.Strings(layoutSeat.Accessibility)
+		if seat.CompanionOfID != nil {
+			layoutSeat.CompanionOf = numbers[*seat.CompanionOfID]
+		}
+
+		layout.Rows[index].Seats = append(layout.Rows[index].Seats, layoutSeat)
+	}
+
+	raw, err := json.Marshal(layout)
+	if err != nil {
+		return err
+	}
+	screen.Layout = string(raw)
+	screen.Capacity = len(seats)
+
+	return tx.Model(screen).Updates(map[string]interface{}{
+		"layout":   screen.Layout,
+		"capacity": screen.Capacity,
+	}).Error
+}
+
+// seatBookedAhead reports whether a seat is sold for a show that has not started
+func seatBookedAhead(db *gorm.DB, seatID uint) (bool, error) {
+	var count int64
+	err := db.Table("booking_seats").
+		Joins("JOIN bookings ON bookings.id = booking_seats.booking_id").
+		Joins("JOIN show_times ON show_times.id = bookings.show_time_id").
+		Where("booking_seats.seat_id = ? AND bookings.status != ? AND show_times.start_time > ?",
+			seatID, "cancelled", time.Now()).
+		Count(&count).Error
+	return count > 0, err
+}
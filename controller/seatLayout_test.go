@@ -0,0 +1,160 @@
+package controller
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseSeatLayoutGridText(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		labels []string
+		specs  []string
+	}{
+		{"labelled rows", "A: ssss\nB: pppp", []string{"A", "B"}, []string{"ssss", "pppp"}},
+		{"unlabelled rows", "ssss\npppp", []string{"", ""}, []string{"ssss", "pppp"}},
+		{"blank lines and CRLF", "A: ss\r\n\r\n\nB: vv\r\n", []string{"A", "B"}, []string{"ss", "vv"}},
+		{"spaces around label", "  AA :  s|s  ", []string{"AA"}, []string{"s|s"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			layout, err := parseSeatLayout(tt.text)
+			if err != nil {
+				t.Fatalf("parseSeatLayout failed: %v", err)
+			}
+			if len(layout.Rows) != len(tt.labels) {
+				t.Fatalf("got %d rows, want %d", len(layout.Rows), len(tt.labels))
+			}
+			for i, row := range layout.Rows {
+				if row.Label != tt.labels[i] || row.Spec != tt.specs[i] {
+					t.Errorf("row %d = %q %q, want %q %q", i, row.Label, row.Spec, tt.labels[i], tt.specs[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseSeatLayoutObject(t *testing.T) {
+	value := map[string]interface{}{
+		"rows": []interface{}{
+			map[string]interface{}{"label": "A", "spec": "ss"},
+			map[string]interface{}{"label": "B", "seats": []interface{}{
+				map[string]interface{}{"number": 3.0, "x": 0.5},
+			}},
+		},
+	}
+
+	layout, err := parseSeatLayout(value)
+	if err != nil {
+		t.Fatalf("parseSeatLayout failed: %v", err)
+	}
+	if len(layout.Rows) != 2 || layout.Rows[1].Seats[0].Number != 3 || layout.Rows[1].Seats[0].X != 0.5 {
+		t.Errorf("unexpected layout %+v", layout)
+	}
+
+	if _, err := parseSeatLayout(map[string]interface{}{"rows": "A: ss"}); err == nil {
+		t.Error("rows given as a string should be rejected")
+	}
+}
+
+func TestBuildSeatsFromSpec(t *testing.T) {
+	tests := []struct {
+		name string
+		row  LayoutRow
+		// want lists "number:category:x" for every seat of the row
+		want []string
+	}{
+		{"plain row", LayoutRow{Label: "A", Spec: "sss"}, []string{"1:standard:0", "2:standard:1", "3:standard:2"}},
+		{"aisle and gap are skipped", LayoutRow{Label: "A", Spec: "s|_p"}, []string{"1:standard:0", "2:premium:3"}},
+		{"start number", LayoutRow{Label: "A", Spec: "vv", StartNumber: 101}, []string{"101:vip:0", "102:vip:1"}},
+		{"spaces are gaps", LayoutRow{Label: "A", Spec: "s s"}, []string{"1:standard:0", "2:standard:2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seats, err := buildSeats(SeatLayout{Rows: []LayoutRow{tt.row}})
+			if err != nil {
+				t.Fatalf("buildSeats failed: %v", err)
+			}
+			var got []string
+			for _, seat := range seats {
+				got = append(got, fmt.Sprintf("%d:%s:%g", seat.Number, seat.Category, seat.X))
+			}
+			if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+				t.Errorf("seats = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildSeatsRowsAndAccessibility(t *testing.T) {
+	seats, err := buildSeats(SeatLayout{Rows: []LayoutRow{
+		{Spec: "ss"},
+		{Spec: "wc|ss"},
+	}})
+	if err != nil {
+		t.Fatalf("buildSeats failed: %v", err)
+	}
+
+	if seats[0].Row != "A" || seats[0].Y != 0 || seats[2].Row != "B" || seats[2].Y != 1 {
+		t.Errorf("rows should be lettered and placed in order, got %+v", seats)
+	}
+	if !seats[2].Wheelchair || !seats[3].Companion || seats[3].companionOf != seats[2].Number {
+		t.Errorf("companion seat should belong to the wheelchair space next to it, got %+v %+v", seats[2], seats[3])
+	}
+}
+
+func TestBuildSeatsErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		layout SeatLayout
+		want   string
+	}{
+		{"no rows", SeatLayout{}, "at least one row"},
+		{"duplicate label", SeatLayout{Rows: []LayoutRow{{Label: "A", Spec: "s"}, {Label: "A", Spec: "s"}}}, "appears twice"},
+		{"unknown character", SeatLayout{Rows: []LayoutRow{{Label: "A", Spec: "sx"}}}, "unknown spec character"},
+		{"spec and seats", SeatLayout{Rows: []LayoutRow{{Label: "A", Spec: "s", Seats: []LayoutSeat{{Number: 1}}}}}, "either spec or seats"},
+		{"only aisles", SeatLayout{Rows: []LayoutRow{{Label: "A", Spec: "||"}}}, "no seats"},
+		{"companion alone", SeatLayout{Rows: []LayoutRow{{Label: "A", Spec: "sc"}}}, "needs a wheelchair space"},
+		{"duplicate seat number", SeatLayout{Rows: []LayoutRow{{Label: "A", Seats: []LayoutSeat{{Number: 1}, {Number: 1, X: 1}}}}}, "seat 1 appears twice"},
+		{"unknown category", SeatLayout{Rows: []LayoutRow{{Label: "A", Category: "gold", Spec: "s"}}}, "unknown category"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := buildSeats(tt.layout)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDefaultSeatLayout(t *testing.T) {
+	tests := []struct {
+		capacity int
+		lengths  []int
+	}{
+		{0, nil},
+		{5, []int{1, 1, 1, 1, 1}},
+		{80, []int{10, 10, 10, 10, 10, 10, 10, 10}},
+		{83, []int{10, 10, 10, 10, 10, 11, 11, 11}},
+	}
+
+	for _, tt := range tests {
+		layout := defaultSeatLayout(tt.capacity)
+		var lengths []int
+		total := 0
+		for _, row := range layout.Rows {
+			lengths = append(lengths, len(row.Spec))
+			total += len(row.Spec)
+		}
+		if total != tt.capacity || !slices.Equal(lengths, tt.lengths) {
+			t.Errorf("defaultSeatLayout(%d) rows = %v, want %v", tt.capacity, lengths, tt.lengths)
+		}
+	}
+}
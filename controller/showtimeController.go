@@ -1,6 +1,8 @@
 package controller
 
 import (
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/SaharKhamseh/cinema-backend/database"
@@ -38,50 +40,184 @@ func CreateShowTime(c *fiber.Ctx) error {
 
 	// Verify movie exists
 	var movie models.Movie
-	if err := database.DB.First(&movie, data["movie_id"]).Error; err != nil {
+	if err := database.DB.Scopes(inChain(c)).First(&movie, data["movie_id"]).Error; err != nil {
 		return c.Status(404).JSON(fiber.Map{
 			"message": "Movie not found",
 		})
 	}
 
-	// Calculate end time based on movie duration
-	endTime := startTime.Add(time.Minute * time.Duration(movie.Duration))
+	// Verify screen exists
+	var screen models.Screen
+	if err := database.DB.Scopes(screensInChain(c)).First(&screen, data["screen_id"]).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"message": "Screen not found",
+		})
+	}
 
-	// Check for time conflicts
-	var conflictingShows int64
-	database.DB.Model(&models.ShowTime{}).
-		Where("screen_id = ? AND ((start_time BETWEEN ? AND ?) OR (end_time BETWEEN ? AND ?))",
-			data["screen_id"], startTime, endTime, startTime, endTime).
-		Count(&conflictingShows)
+	// The screen has to be able to project the format, 2D by default
+	var format models.Format
+	if data["format_id"] != nil {
+		if err := database.DB.Scopes(inChain(c)).First(&format, data["format_id"]).Error; err != nil {
+			return c.Status(404).JSON(fiber.Map{
+				"message": "Format not found",
+			})
+		}
+	} else if err := database.DB.Scopes(inChain(c)).Where("code = ?", "2d").First(&format).Error; err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "format_id is required",
+		})
+	}
 
-	if conflictingShows > 0 {
+	if !screenSupports(screen.ID, format.ID) {
 		return c.Status(400).JSON(fiber.Map{
-			"message": "Time slot conflicts with existing show",
+			"message": "Screen does not support the " + format.Name + " format",
 		})
 	}
 
+	// The pre-roll of ads and trailers comes from the screen unless given
+	preRoll := screen.PreRollMinutes
+	if data["pre_roll_minutes"] != nil {
+		minutes, ok := data["pre_roll_minutes"].(float64)
+		if !ok || minutes < 0 || minutes > 240 {
+			return c.Status(400).JSON(fiber.Map{
+				"message": "pre_roll_minutes must be between 0 and 240",
+			})
+		}
+		preRoll = int(minutes)
+	}
+
+	// Calculate end time based on pre-roll and movie duration
+	endTime := showEndTime(startTime, preRoll, movie.Duration)
+
+	// Check opening hours and the cleaning buffer to other shows
+	if err := checkSchedule(screen, startTime, endTime, 0); err != nil {
+		return scheduleErrorResponse(c, err)
+	}
+
 	showTime := models.ShowTime{
 		MovieID:   uint(data["movie_id"].(float64)),
 		ScreenID:  uint(data["screen_id"].(float64)),
 		StartTime: startTime,
 		EndTime:   endTime,
 		Price:     data["price"].(float64),
+
+		PreRollMinutes: preRoll,
+		FormatID:       &format.ID,
+		Format:         &format,
+		Surcharge:      format.Surcharge,
+
+		AudioLanguage: movie.Language,
+	}
+
+	if err := applyScreeningOptions(&showTime, data); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	tx := database.DB.Begin()
+
+	if err := tx.Omit("Format").Create(&showTime).Error; err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to create show time",
+			"error":   err.Error(),
+		})
 	}
 
-	if err := database.DB.Create(&showTime).Error; err != nil {
+	if err := recordAudit(tx, c, "create", "showtime", showTime.ID, nil, showTime); err != nil {
+		tx.Rollback()
 		return c.Status(500).JSON(fiber.Map{
 			"message": "Failed to create show time",
 			"error":   err.Error(),
 		})
 	}
 
+	// Tell users watching the movie that tickets are on sale
+	if err := notifyWatchers(tx, showTime, screen.TheaterID); err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to create show time",
+			"error":   err.Error(),
+		})
+	}
+
+	tx.Commit()
+
 	return c.Status(201).JSON(fiber.Map{
 		"message":  "Show time created successfully",
 		"showtime": showTime,
 	})
 }
 
-// GetShowTimes returns all show times for a specific date
+// applyScreeningOptions sets the audio language, subtitle language and
+// accessibility flags present in a request body. A null or empty
+// subtitle_language removes the subtitles.
+func applyScreeningOptions(showTime *models.ShowTime, data map[string]interface{}) error {
+	if value, ok := data["audio_language"]; ok {
+		language, isString := value.(string)
+		if !isString || strings.TrimSpace(language) == "" {
+			return fmt.Errorf("audio_language must be a language name")
+		}
+		showTime.AudioLanguage = strings.TrimSpace(language)
+	}
+
+	if value, ok := data["subtitle_language"]; ok {
+		language, isString := value.(string)
+		if value != nil && !isString {
+			return fmt.Errorf("subtitle_language must be a language name")
+		}
+		showTime.SubtitleLanguage = strings.TrimSpace(language)
+	}
+
+	for field, target := range map[string]*bool{
+		"captioned":       &showTime.Captioned,
+		"audio_described": &showTime.AudioDescribed,
+	} {
+		if value, ok := data[field]; ok {
+			flag, isBool := value.(bool)
+			if !isBool {
+				return fmt.Errorf("%s must be true or false", field)
+			}
+			*target = flag
+		}
+	}
+
+	return nil
+}
+
+// screeningLabel describes the version of a show for confirmations,
+// e.g. "IMAX, English, Persian subtitles, captioned"
+func screeningLabel(showTime models.ShowTime) string {
+	var parts []string
+	if showTime.Format != nil {
+		parts = append(parts, showTime.Format.Name)
+	}
+	if showTime.AudioLanguage != "" {
+		parts = append(parts, showTime.AudioLanguage)
+	}
+	if showTime.SubtitleLanguage != "" {
+		parts = append(parts, showTime.SubtitleLanguage+" subtitles")
+	}
+	if showTime.Captioned {
+		parts = append(parts, "captioned")
+	}
+	if showTime.AudioDescribed {
+		parts = append(parts, "audio described")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// showTimeSortFields whitelists the columns GetShowTimes may sort on
+var showTimeSortFields = map[string]cursorField{
+	"start_time": {Column: "show_times.start_time", Time: true},
+	"price":      {Column: "show_times.price"},
+}
+
+// GetShowTimes returns a page of show times for a specific date. Pass
+// ?format=imax,3d to only list shows in those formats, audio_language and
+// subtitle_language (comma separated, "none" for unsubtitled shows) to pick
+// a version, and captioned=true or audio_described=true for accessible shows.
 func GetShowTimes(c *fiber.Ctx) error {
 	date := c.Query("date")
 	if date == "" {
@@ -91,19 +227,50 @@ func GetShowTimes(c *fiber.Ctx) error {
 	startOfDay, _ := time.Parse("2006-01-02", date)
 	endOfDay := startOfDay.Add(24 * time.Hour)
 
-	var showTimes []models.ShowTime
-	if err := database.DB.
-		Preload("Movie").
-		Preload("Screen").
-		Where("start_time BETWEEN ? AND ?", startOfDay, endOfDay).
-		Find(&showTimes).Error; err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"message": "Failed to fetch show times",
-			"error":   err.Error(),
-		})
+	query := database.DB.Model(&models.ShowTime{}).
+		Scopes(showTimesInChain(c)).
+		Where("start_time BETWEEN ? AND ?", startOfDay, endOfDay)
+
+	if codes := splitList(c.Query("format")); len(codes) > 0 {
+		query = query.Where("format_id IN (SELECT id FROM formats WHERE code IN ?)", codes)
+	}
+	if languages := splitList(c.Query("audio_language")); len(languages) > 0 {
+		query = query.Where("audio_language IN ?", languages)
+	}
+	if languages := splitList(c.Query("subtitle_language")); len(languages) > 0 {
+		for i, language := range languages {
+			if strings.EqualFold(language, "none") {
+				languages[i] = ""
+			}
+		}
+		query = query.Where("subtitle_language IN ?", languages)
+	}
+	if c.QueryBool("captioned") {
+		query = query.Where("captioned = ?", true)
+	}
+	if c.QueryBool("audio_described") {
+		query = query.Where("audio_described = ?", true)
+	}
+
+	showTimes, meta, err := paginateCursor(c, query, showTimeSortFields, "start_time",
+		func(showTime models.ShowTime, field string) (uint, interface{}) {
+			if field == "price" {
+				return showTime.ID, showTime.Price
+			}
+			return showTime.ID, showTime.StartTime
+		},
+		preload("Movie", "Screen", "Format"))
+	if err != nil {
+		return pageErrorResponse(c, err, "Failed to fetch show times")
 	}
 
-	return c.JSON(showTimes)
+	pointers := make([]*models.Movie, len(showTimes))
+	for i := range showTimes {
+		pointers[i] = &showTimes[i].Movie
+	}
+	localizeMovies(c, pointers...)
+
+	return c.JSON(pageEnvelope(showTimes, meta))
 }
 
 // GetShowTime returns a specific show time
@@ -112,14 +279,18 @@ func GetShowTime(c *fiber.Ctx) error {
 	var showTime models.ShowTime
 
 	if err := database.DB.
+		Scopes(showTimesInChain(c)).
 		Preload("Movie").
 		Preload("Screen").
+		Preload("Format").
 		First(&showTime, id).Error; err != nil {
 		return c.Status(404).JSON(fiber.Map{
 			"message": "Show time not found",
 		})
 	}
 
+	localizeMovies(c, &showTime.Movie)
+
 	return c.JSON(showTime)
 }
 
@@ -128,7 +299,7 @@ func UpdateShowTime(c *fiber.Ctx) error {
 	id := c.Params("id")
 	var showTime models.ShowTime
 
-	if err := database.DB.First(&showTime, id).Error; err != nil {
+	if err := database.DB.Scopes(showTimesInChain(c)).First(&showTime, id).Error; err != nil {
 		return c.Status(404).JSON(fiber.Map{
 			"message": "Show time not found",
 		})
@@ -141,6 +312,8 @@ func UpdateShowTime(c *fiber.Ctx) error {
 		})
 	}
 
+	before := showTime
+
 	if data["start_time"] != nil {
 		startTime, err := time.Parse("2006-01-02 15:04:05", data["start_time"].(string))
 		if err != nil {
@@ -149,49 +322,147 @@ func UpdateShowTime(c *fiber.Ctx) error {
 			})
 		}
 		showTime.StartTime = startTime
+	}
 
+	if data["pre_roll_minutes"] != nil {
+		minutes, ok := data["pre_roll_minutes"].(float64)
+		if !ok || minutes < 0 || minutes > 240 {
+			return c.Status(400).JSON(fiber.Map{
+				"message": "pre_roll_minutes must be between 0 and 240",
+			})
+		}
+		showTime.PreRollMinutes = int(minutes)
+	}
+
+	if data["start_time"] != nil || data["pre_roll_minutes"] != nil {
 		// Recalculate end time
 		var movie models.Movie
-		database.DB.First(&movie, showTime.MovieID)
-		showTime.EndTime = startTime.Add(time.Minute * time.Duration(movie.Duration))
+		database.DB.Unscoped().First(&movie, showTime.MovieID)
+		showTime.EndTime = showEndTime(showTime.StartTime, showTime.PreRollMinutes, movie.Duration)
+
+		var screen models.Screen
+		if err := database.DB.Unscoped().First(&screen, showTime.ScreenID).Error; err != nil {
+			return c.Status(404).JSON(fiber.Map{
+				"message": "Screen not found",
+			})
+		}
+		if err := checkSchedule(screen, showTime.StartTime, showTime.EndTime, showTime.ID); err != nil {
+			return scheduleErrorResponse(c, err)
+		}
 	}
 
 	if data["price"] != nil {
 		showTime.Price = data["price"].(float64)
 	}
 
-	if err := database.DB.Save(&showTime).Error; err != nil {
+	if data["format_id"] != nil {
+		var format models.Format
+		if err := database.DB.Scopes(inChain(c)).First(&format, data["format_id"]).Error; err != nil {
+			return c.Status(404).JSON(fiber.Map{
+				"message": "Format not found",
+			})
+		}
+		if !screenSupports(showTime.ScreenID, format.ID) {
+			return c.Status(400).JSON(fiber.Map{
+				"message": "Screen does not support the " + format.Name + " format",
+			})
+		}
+		showTime.FormatID = &format.ID
+		showTime.Surcharge = format.Surcharge
+	}
+
+	if err := applyScreeningOptions(&showTime, data); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	tx := database.DB.Begin()
+
+	if err := tx.Save(&showTime).Error; err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to update show time",
+			"error":   err.Error(),
+		})
+	}
+
+	if err := recordAudit(tx, c, "update", "showtime", showTime.ID, before, showTime); err != nil {
+		tx.Rollback()
 		return c.Status(500).JSON(fiber.Map{
 			"message": "Failed to update show time",
 			"error":   err.Error(),
 		})
 	}
 
+	tx.Commit()
+
 	return c.JSON(fiber.Map{
 		"message":  "Show time updated successfully",
 		"showtime": showTime,
 	})
 }
 
-// DeleteShowTime deletes a show time
+// DeleteShowTime soft-deletes a show time. A future show with active
+// bookings is only deleted with ?cascade=true, which cancels the bookings.
 func DeleteShowTime(c *fiber.Ctx) error {
 	id := c.Params("id")
 	var showTime models.ShowTime
 
-	if err := database.DB.First(&showTime, id).Error; err != nil {
+	if err := database.DB.Scopes(showTimesInChain(c)).First(&showTime, id).Error; err != nil {
 		return c.Status(404).JSON(fiber.Map{
 			"message": "Show time not found",
 		})
 	}
 
-	if err := database.DB.Delete(&showTime).Error; err != nil {
+	impact, err := futureShowTimeImpact("id = ?", showTime.ID)
+	if err != nil {
 		return c.Status(500).JSON(fiber.Map{
 			"message": "Failed to delete show time",
 			"error":   err.Error(),
 		})
 	}
 
+	// The show itself is what is being deleted, only its bookings block it
+	cascade := c.QueryBool("cascade")
+	if impact.ActiveBookings > 0 && !cascade {
+		return blockedDeletion(c, impact)
+	}
+
+	tx := database.DB.Begin()
+
+	if impact.ActiveBookings > 0 {
+		if err := tx.Model(&models.Booking{}).
+			Where("show_time_id = ? AND status != ?", showTime.ID, "cancelled").
+			Updates(bookingCancellation()).Error; err != nil {
+			tx.Rollback()
+			return c.Status(500).JSON(fiber.Map{
+				"message": "Failed to delete show time",
+				"error":   err.Error(),
+			})
+		}
+	}
+
+	if err := tx.Delete(&showTime).Error; err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to delete show time",
+			"error":   err.Error(),
+		})
+	}
+
+	if err := recordAudit(tx, c, "delete", "showtime", showTime.ID, showTime, nil); err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to delete show time",
+			"error":   err.Error(),
+		})
+	}
+
+	tx.Commit()
+
 	return c.JSON(fiber.Map{
 		"message": "Show time deleted successfully",
+		"impact":  impact,
 	})
 }
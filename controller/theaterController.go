@@ -1,11 +1,20 @@
 package controller
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/SaharKhamseh/cinema-backend/database"
+	"github.com/SaharKhamseh/cinema-backend/middleware"
 	"github.com/SaharKhamseh/cinema-backend/models"
 	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm/clause"
 )
 
+// defaultAccessibleReleaseMinutes is how long before a show a new theater
+// puts its held accessible seats on general sale
+const defaultAccessibleReleaseMinutes = 60
+
 // CreateTheater creates a new theater with screens
 func CreateTheater(c *fiber.Ctx) error {
 	var data map[string]interface{}
@@ -24,17 +33,46 @@ func CreateTheater(c *fiber.Ctx) error {
 	}
 
 	theater := models.Theater{
-		Name:     data["name"].(string),
-		Capacity: int(data["capacity"].(float64)),
+		ChainID:                  chainID(c),
+		Name:                     data["name"].(string),
+		Capacity:                 int(data["capacity"].(float64)),
+		AccessibleReleaseMinutes: defaultAccessibleReleaseMinutes,
+	}
+	if minutes, ok := data["accessible_release_minutes"].(float64); ok {
+		if minutes < 0 {
+			return c.Status(400).JSON(fiber.Map{
+				"message": "accessible_release_minutes cannot be negative",
+			})
+		}
+		theater.AccessibleReleaseMinutes = int(minutes)
+	}
+	if err := applyTheaterDetails(&theater, data); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	tx := database.DB.Begin()
+
+	if err := tx.Create(&theater).Error; err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to create theater",
+			"error":   err.Error(),
+		})
 	}
 
-	if err := database.DB.Create(&theater).Error; err != nil {
+	if err := recordAudit(tx, c, "create", "theater", theater.ID, nil, theater); err != nil {
+		tx.Rollback()
 		return c.Status(500).JSON(fiber.Map{
 			"message": "Failed to create theater",
 			"error":   err.Error(),
 		})
 	}
 
+	tx.Commit()
+	invalidateTheaterIndex()
+
 	return c.Status(201).JSON(fiber.Map{
 		"message": "Theater created successfully",
 		"theater": theater,
@@ -52,7 +90,7 @@ func CreateScreen(c *fiber.Ctx) error {
 	}
 
 	// Validate required fields
-	requiredFields := []string{"theater_id", "name", "capacity"}
+	requiredFields := []string{"theater_id", "name"}
 	for _, field := range requiredFields {
 		if data[field] == nil {
 			return c.Status(400).JSON(fiber.Map{
@@ -61,21 +99,65 @@ func CreateScreen(c *fiber.Ctx) error {
 		}
 	}
 
+	// Seats come from the layout, or from a plain capacity
+	var layout SeatLayout
+	if data["layout"] != nil {
+		var err error
+		if layout, err = parseSeatLayout(data["layout"]); err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+	} else if capacity, ok := data["capacity"].(float64); ok && capacity > 0 {
+		layout = defaultSeatLayout(int(capacity))
+	} else {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "layout or capacity is required",
+		})
+	}
+
+	if _, err := buildSeats(layout); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
 	// Check if theater exists
 	var theater models.Theater
-	if err := database.DB.First(&theater, data["theater_id"]).Error; err != nil {
+	if err := database.DB.Scopes(inChain(c)).First(&theater, data["theater_id"]).Error; err != nil {
 		return c.Status(404).JSON(fiber.Map{
 			"message": "Theater not found",
 		})
 	}
 
+	// Screens project 2D unless told otherwise
+	var formats []models.Format
+	if data["format_ids"] != nil {
+		var err error
+		if formats, err = findFormats(database.DB.Scopes(inChain(c)), data["format_ids"]); err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+	} else {
+		database.DB.Scopes(inChain(c)).Where("code = ?", "2d").Find(&formats)
+	}
+
 	screen := models.Screen{
 		TheaterID: uint(data["theater_id"].(float64)),
 		Name:      data["name"].(string),
-		Capacity:  int(data["capacity"].(float64)),
+		Formats:   formats,
+	}
+	if err := applyScreenTimings(&screen, data); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": err.Error(),
+		})
 	}
 
-	if err := database.DB.Create(&screen).Error; err != nil {
+	tx := database.DB.Begin()
+
+	if err := tx.Create(&screen).Error; err != nil {
+		tx.Rollback()
 		return c.Status(500).JSON(fiber.Map{
 			"message": "Failed to create screen",
 			"error":   err.Error(),
@@ -83,52 +165,44 @@ func CreateScreen(c *fiber.Ctx) error {
 	}
 
 	// Create seats for the screen
-	if err := createSeatsForScreen(&screen); err != nil {
+	if err := applySeatLayout(tx, &screen, layout); err != nil {
+		tx.Rollback()
+		return layoutErrorResponse(c, err, "Failed to create seats")
+	}
+
+	if err := recordAudit(tx, c, "create", "screen", screen.ID, nil, screen); err != nil {
+		tx.Rollback()
 		return c.Status(500).JSON(fiber.Map{
-			"message": "Failed to create seats",
+			"message": "Failed to create screen",
 			"error":   err.Error(),
 		})
 	}
 
+	tx.Commit()
+
 	return c.Status(201).JSON(fiber.Map{
 		"message": "Screen created successfully",
 		"screen":  screen,
 	})
 }
 
-// Helper function to create seats for a screen
-func createSeatsForScreen(screen *models.Screen) error {
-	rows := []string{"A", "B", "C", "D", "E", "F", "G", "H"}
-	seatsPerRow := screen.Capacity / len(rows)
-
-	for _, row := range rows {
-		for i := 1; i <= seatsPerRow; i++ {
-			seat := models.Seat{
-				ScreenID: screen.ID,
-				Row:      row,
-				Number:   i,
-				Category: "standard", // You can modify this based on row position
-			}
-			if err := database.DB.Create(&seat).Error; err != nil {
-				return err
-			}
-		}
-	}
-	return nil
+// theaterSortFields whitelists the columns GetTheaters may sort on
+var theaterSortFields = map[string]string{
+	"id":       "id",
+	"name":     "name",
+	"capacity": "capacity",
 }
 
-// GetTheaters returns all theaters with their screens
+// GetTheaters returns a page of theaters with their screens
 func GetTheaters(c *fiber.Ctx) error {
 	var theaters []models.Theater
 
-	if err := database.DB.Preload("Screens").Find(&theaters).Error; err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"message": "Failed to fetch theaters",
-			"error":   err.Error(),
-		})
+	meta, err := paginateOffset(c, database.DB.Scopes(inChain(c)), &theaters, theaterSortFields, "name ASC", preload("Screens"))
+	if err != nil {
+		return pageErrorResponse(c, err, "Failed to fetch theaters")
 	}
 
-	return c.JSON(theaters)
+	return c.JSON(pageEnvelope(theaters, meta))
 }
 
 // GetTheater returns a specific theater with its screens
@@ -136,7 +210,7 @@ func GetTheater(c *fiber.Ctx) error {
 	id := c.Params("id")
 	var theater models.Theater
 
-	if err := database.DB.Preload("Screens").First(&theater, id).Error; err != nil {
+	if err := database.DB.Scopes(inChain(c)).Preload("Screens").First(&theater, id).Error; err != nil {
 		return c.Status(404).JSON(fiber.Map{
 			"message": "Theater not found",
 		})
@@ -150,7 +224,7 @@ func GetScreenSeats(c *fiber.Ctx) error {
 	screenID := c.Params("id")
 	var seats []models.Seat
 
-	if err := database.DB.Where("screen_id = ?", screenID).Find(&seats).Error; err != nil {
+	if err := database.DB.Scopes(seatsInChain(c)).Where("screen_id = ?", screenID).Find(&seats).Error; err != nil {
 		return c.Status(500).JSON(fiber.Map{
 			"message": "Failed to fetch seats",
 			"error":   err.Error(),
@@ -159,3 +233,716 @@ func GetScreenSeats(c *fiber.Ctx) error {
 
 	return c.JSON(seats)
 }
+
+// DeleteTheater soft-deletes a theater and its screens. Future show times
+// or active bookings on any of its screens block the deletion unless
+// ?cascade=true is given.
+func DeleteTheater(c *fiber.Ctx) error {
+	id := c.Params("id")
+	var theater models.Theater
+
+	if err := database.DB.Scopes(inChain(c)).Preload("Screens").First(&theater, id).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"message": "Theater not found",
+		})
+	}
+
+	impact, err := futureShowTimeImpact("screen_id IN (?)",
+		database.DB.Model(&models.Screen{}).Select("id").Where("theater_id = ?", theater.ID))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to delete theater",
+			"error":   err.Error(),
+		})
+	}
+
+	if impact.blocking() && !c.QueryBool("cascade") {
+		return blockedDeletion(c, impact)
+	}
+
+	tx := database.DB.Begin()
+
+	if err := cascadeShowTimes(tx, c, impact.FutureShowTimes); err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to delete theater",
+			"error":   err.Error(),
+		})
+	}
+
+	for _, screen := range theater.Screens {
+		if err := tx.Delete(&screen).Error; err != nil {
+			tx.Rollback()
+			return c.Status(500).JSON(fiber.Map{
+				"message": "Failed to delete theater",
+				"error":   err.Error(),
+			})
+		}
+		if err := recordAudit(tx, c, "delete", "screen", screen.ID, screen, nil); err != nil {
+			tx.Rollback()
+			return c.Status(500).JSON(fiber.Map{
+				"message": "Failed to delete theater",
+				"error":   err.Error(),
+			})
+		}
+	}
+
+	if err := tx.Delete(&theater).Error; err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to delete theater",
+			"error":   err.Error(),
+		})
+	}
+
+	if err := recordAudit(tx, c, "delete", "theater", theater.ID, theater, nil); err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to delete theater",
+			"error":   err.Error(),
+		})
+	}
+
+	tx.Commit()
+	invalidateTheaterIndex()
+
+	return c.JSON(fiber.Map{
+		"message": "Theater deleted successfully",
+		"impact":  impact,
+	})
+}
+
+// DeleteScreen soft-deletes a screen. Future show times or active bookings
+// block the deletion unless ?cascade=true is given.
+func DeleteScreen(c *fiber.Ctx) error {
+	id := c.Params("id")
+	var screen models.Screen
+
+	if err := database.DB.Scopes(screensInChain(c)).First(&screen, id).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"message": "Screen not found",
+		})
+	}
+
+	impact, err := futureShowTimeImpact("screen_id = ?", screen.ID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to delete screen",
+			"error":   err.Error(),
+		})
+	}
+
+	if impact.blocking() && !c.QueryBool("cascade") {
+		return blockedDeletion(c, impact)
+	}
+
+	tx := database.DB.Begin()
+
+	if err := cascadeShowTimes(tx, c, impact.FutureShowTimes); err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to delete screen",
+			"error":   err.Error(),
+		})
+	}
+
+	if err := tx.Delete(&screen).Error; err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to delete screen",
+			"error":   err.Error(),
+		})
+	}
+
+	if err := recordAudit(tx, c, "delete", "screen", screen.ID, screen, nil); err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to delete screen",
+			"error":   err.Error(),
+		})
+	}
+
+	tx.Commit()
+
+	return c.JSON(fiber.Map{
+		"message": "Screen deleted successfully",
+		"impact":  impact,
+	})
+}
+
+// licenseFields are the theater settings that need PermLicenseTheater
+var licenseFields = []string{"capacity", "accessible_release_minutes"}
+
+// lockedLicenseField returns the first license field in data that the
+// caller cannot change, given the caller's permissions at the theater
+func lockedLicenseField(data map[string]interface{}, allows func(permission string) bool) string {
+	for _, field := range licenseFields {
+		if data[field] != nil && !allows(models.PermLicenseTheater) {
+			return field
+		}
+	}
+	return ""
+}
+
+// UpdateTheater renames a theater or changes its details. The capacity and
+// accessible seat release time are left to chain admins.
+func UpdateTheater(c *fiber.Ctx) error {
+	id := c.Params("id")
+	var theater models.Theater
+
+	if err := database.DB.Scopes(inChain(c)).First(&theater, id).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"message": "Theater not found",
+		})
+	}
+
+	var data map[string]interface{}
+	if err := c.BodyParser(&data); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Invalid request body",
+		})
+	}
+
+	userID, _ := currentUserID(c)
+	allows := func(permission string) bool {
+		return middleware.HasTheaterPermission(userID, theater.ID, permission)
+	}
+	if field := lockedLicenseField(data, allows); field != "" {
+		return c.Status(403).JSON(fiber.Map{
+			"message": fmt.Sprintf("Only chain admins can change %s", field),
+		})
+	}
+
+	before := theater
+
+	if data["name"] != nil {
+		name, _ := data["name"].(string)
+		if strings.TrimSpace(name) == "" {
+			return c.Status(400).JSON(fiber.Map{
+				"message": "name cannot be empty",
+			})
+		}
+		theater.Name = strings.TrimSpace(name)
+	}
+	if data["capacity"] != nil {
+		capacity, ok := data["capacity"].(float64)
+		if !ok || capacity < 0 {
+			return c.Status(400).JSON(fiber.Map{
+				"message": "capacity must be a positive number",
+			})
+		}
+		theater.Capacity = int(capacity)
+
+		// The licensed capacity cannot drop below the seats already installed
+		seats, err := theaterSeatCount(database.DB, theater.ID, 0)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{
+				"message": "Failed to update theater",
+				"error":   err.Error(),
+			})
+		}
+		if seats > theater.Capacity {
+			return c.Status(409).JSON(fiber.Map{
+				"message":  "The screens would have more seats than the theater is licensed for",
+				"licensed": theater.Capacity,
+				"seats":    seats,
+			})
+		}
+	}
+	if data["accessible_release_minutes"] != nil {
+		minutes, ok := data["accessible_release_minutes"].(float64)
+		if !ok || minutes < 0 {
+			return c.Status(400).JSON(fiber.Map{
+				"message": "accessible_release_minutes cannot be negative",
+			})
+		}
+		theater.AccessibleReleaseMinutes = int(minutes)
+	}
+	if err := applyTheaterDetails(&theater, data); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	tx := database.DB.Begin()
+
+	if err := tx.Omit(clause.Associations).Save(&theater).Error; err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to update theater",
+			"error":   err.Error(),
+		})
+	}
+
+	if err := recordAudit(tx, c, "update", "theater", theater.ID, before, theater); err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to update theater",
+			"error":   err.Error(),
+		})
+	}
+
+	tx.Commit()
+	invalidateTheaterIndex()
+
+	return c.JSON(fiber.Map{
+		"message": "Theater updated successfully",
+		"theater": theater,
+	})
+}
+
+// MergeTheater moves every screen of a theater, with their shows and
+// bookings, into another theater and soft-deletes the emptied one. It is
+// the safe way to clean up a duplicate theater.
+func MergeTheater(c *fiber.Ctx) error {
+	id := c.Params("id")
+	var source models.Theater
+
+	if err := database.DB.Scopes(inChain(c)).First(&source, id).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"message": "Theater not found",
+		})
+	}
+
+	// Deleted screens move too, so they and their history can still be restored
+	if err := database.DB.Unscoped().Where("theater_id = ?", source.ID).Find(&source.Screens).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to merge theaters",
+			"error":   err.Error(),
+		})
+	}
+
+	var data map[string]interface{}
+	if err := c.BodyParser(&data); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Invalid request body",
+		})
+	}
+
+	if data["into"] == nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "into is required",
+		})
+	}
+
+	var target models.Theater
+	if err := database.DB.Scopes(inChain(c)).First(&target, data["into"]).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"message": "Target theater not found",
+		})
+	}
+	if target.ID == source.ID {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "A theater cannot be merged into itself",
+		})
+	}
+
+	tx := database.DB.Begin()
+
+	for _, screen := range source.Screens {
+		before := screen
+		screen.TheaterID = target.ID
+		if err := tx.Unscoped().Model(&screen).Update("theater_id", target.ID).Error; err != nil {
+			tx.Rollback()
+			return c.Status(500).JSON(fiber.Map{
+				"message": "Failed to merge theaters",
+				"error":   err.Error(),
+			})
+		}
+		if err := recordAudit(tx, c, "update", "screen", screen.ID, before, screen); err != nil {
+			tx.Rollback()
+			return c.Status(500).JSON(fiber.Map{
+				"message": "Failed to merge theaters",
+				"error":   err.Error(),
+			})
+		}
+	}
+
+	// Customers who preferred the old theater now prefer the merged one
+	if err := tx.Exec(`INSERT INTO preferred_theaters (user_id, theater_id)
+		SELECT user_id, ? FROM preferred_theaters p
+		WHERE p.theater_id = ? AND NOT EXISTS
+			(SELECT 1 FROM preferred_theaters q WHERE q.user_id = p.user_id AND q.theater_id = ?)`,
+		target.ID, source.ID, target.ID).Error; err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to merge theaters",
+			"error":   err.Error(),
+		})
+	}
+	if err := tx.Where("theater_id = ?", source.ID).Delete(&models.PreferredTheater{}).Error; err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to merge theaters",
+			"error":   err.Error(),
+		})
+	}
+
+	// Staff keep their roles at the merged theater
+	if err := tx.Exec(`INSERT INTO role_assignments (user_id, theater_id, role, granted_by, created_at)
+		SELECT user_id, ?, role, granted_by, created_at FROM role_assignments r
+		WHERE r.theater_id = ? AND NOT EXISTS
+			(SELECT 1 FROM role_assignments q WHERE q.user_id = r.user_id AND q.theater_id = ? AND q.role = r.role)`,
+		target.ID, source.ID, target.ID).Error; err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to merge theaters",
+			"error":   err.Error(),
+		})
+	}
+	if err := tx.Where("theater_id = ?", source.ID).Delete(&models.RoleAssignment{}).Error; err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to merge theaters",
+			"error":   err.Error(),
+		})
+	}
+
+	target.Capacity += source.Capacity
+	if err := tx.Model(&target).Update("capacity", target.Capacity).Error; err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to merge theaters",
+			"error":   err.Error(),
+		})
+	}
+
+	if err := tx.Delete(&source).Error; err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to merge theaters",
+			"error":   err.Error(),
+		})
+	}
+
+	if err := recordAudit(tx, c, "merge", "theater", source.ID, source, target); err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to merge theaters",
+			"error":   err.Error(),
+		})
+	}
+
+	tx.Commit()
+	invalidateTheaterIndex()
+
+	database.DB.Preload("Screens").First(&target, target.ID)
+
+	return c.JSON(fiber.Map{
+		"message": "Theaters merged successfully",
+		"theater": target,
+	})
+}
+
+// UpdateScreen renames a screen or changes its cleaning, turnaround and
+// pre-roll minutes, which apply to shows scheduled from now on. Sending
+// capacity replaces the seat layout with the default one for that
+// capacity, which is refused when it would remove seats sold for upcoming
+// shows.
+func UpdateScreen(c *fiber.Ctx) error {
+	id := c.Params("id")
+	var screen models.Screen
+
+	if err := database.DB.Scopes(screensInChain(c)).First(&screen, id).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"message": "Screen not found",
+		})
+	}
+
+	var data map[string]interface{}
+	if err := c.BodyParser(&data); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Invalid request body",
+		})
+	}
+
+	if data["theater_id"] != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Screens cannot move between theaters, merge the theaters instead",
+		})
+	}
+
+	before := screen
+
+	if data["name"] != nil {
+		name, _ := data["name"].(string)
+		if strings.TrimSpace(name) == "" {
+			return c.Status(400).JSON(fiber.Map{
+				"message": "name cannot be empty",
+			})
+		}
+		screen.Name = strings.TrimSpace(name)
+	}
+	if err := applyScreenTimings(&screen, data); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	tx := database.DB.Begin()
+
+	if err := tx.Model(&screen).Updates(map[string]interface{}{
+		"name":               screen.Name,
+		"cleaning_minutes":   screen.CleaningMinutes,
+		"turnaround_minutes": screen.TurnaroundMinutes,
+		"pre_roll_minutes":   screen.PreRollMinutes,
+	}).Error; err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to update screen",
+			"error":   err.Error(),
+		})
+	}
+
+	if data["capacity"] != nil {
+		capacity, ok := data["capacity"].(float64)
+		if !ok || capacity <= 0 {
+			tx.Rollback()
+			return c.Status(400).JSON(fiber.Map{
+				"message": "capacity must be a positive number",
+			})
+		}
+		if err := applySeatLayout(tx, &screen, defaultSeatLayout(int(capacity))); err != nil {
+			tx.Rollback()
+			return layoutErrorResponse(c, err, "Failed to update screen")
+		}
+	}
+
+	if err := recordAudit(tx, c, "update", "screen", screen.ID, before, screen); err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to update screen",
+			"error":   err.Error(),
+		})
+	}
+
+	tx.Commit()
+
+	return c.JSON(fiber.Map{
+		"message": "Screen updated successfully",
+		"screen":  screen,
+	})
+}
+
+// UpdateSeat changes a seat's category, position or accessibility. A seat
+// sold for an upcoming show keeps its row and number so tickets stay valid.
+func UpdateSeat(c *fiber.Ctx) error {
+	id := c.Params("id")
+	var seat models.Seat
+
+	if err := database.DB.Scopes(seatsInChain(c)).First(&seat, id).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"message": "Seat not found",
+		})
+	}
+
+	var data map[string]interface{}
+	if err := c.BodyParser(&data); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Invalid request body",
+		})
+	}
+
+	before := seat
+
+	if data["row"] != nil || data["number"] != nil {
+		booked, err := seatBookedAhead(database.DB, seat.ID)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{
+				"message": "Failed to update seat",
+				"error":   err.Error(),
+			})
+		}
+		if booked {
+			return c.Status(409).JSON(fiber.Map{
+				"message": "Seat is booked for upcoming shows, its row and number cannot change",
+			})
+		}
+
+		if row, ok := data["row"].(string); ok && strings.TrimSpace(row) != "" {
+			seat.Row = strings.TrimSpace(row)
+		}
+		if number, ok := data["number"].(float64); ok && number > 0 {
+			seat.Number = int(number)
+		}
+
+		var taken int64
+		database.DB.Model(&models.Seat{}).
+			Where("screen_id = ? AND `row` = ? AND number = ? AND id <> ?", seat.ScreenID, seat.Row, seat.Number, seat.ID).
+			Count(&taken)
+		if taken > 0 {
+			return c.Status(400).JSON(fiber.Map{
+				"message": "Another seat already has this row and number",
+			})
+		}
+	}
+
+	if category, ok := data["category"].(string); ok {
+		if !seatCategories[category] {
+			return c.Status(400).JSON(fiber.Map{
+				"message": "Unknown seat category",
+			})
+		}
+		seat.Category = category
+	}
+	if x, ok := data["x"].(float64); ok {
+		seat.X = x
+	}
+	if y, ok := data["y"].(float64); ok {
+		seat.Y = y
+	}
+	for field, target := range map[string]*bool{
+		"wheelchair":   &seat.Wheelchair,
+		"easy_access":  &seat.EasyAccess,
+		"hearing_loop": &seat.HearingLoop,
+	} {
+		if value, ok := data[field].(bool); ok {
+			*target = value
+		}
+	}
+	if value, ok := data["companion_of_id"]; ok {
+		seat.Companion = value != nil
+		seat.CompanionOfID = nil
+		if value != nil {
+			id, ok := value.(float64)
+			if !ok || id <= 0 {
+				return c.Status(400).JSON(fiber.Map{
+					"message": "companion_of_id must be a wheelchair space in the same row",
+				})
+			}
+			spaceID := uint(id)
+			seat.CompanionOfID = &spaceID
+		}
+	}
+	// Like in seat layouts, a companion seat belongs to a wheelchair space
+	// in its own row
+	if seat.CompanionOfID != nil {
+		var space models.Seat
+		if err := database.DB.Where("screen_id = ? AND `row` = ? AND wheelchair = ?", seat.ScreenID, seat.Row, true).
+			First(&space, *seat.CompanionOfID).Error; err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"message": "companion_of_id must be a wheelchair space in the same row",
+			})
+		}
+	}
+	if seat.Wheelchair && seat.Companion {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "A seat cannot be both a wheelchair space and a companion seat",
+		})
+	}
+
+	tx := database.DB.Begin()
+
+	if err := tx.Save(&seat).Error; err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to update seat",
+			"error":   err.Error(),
+		})
+	}
+
+	// A space that no longer takes a wheelchair, or moved to another row,
+	// loses its companion seats
+	if before.Wheelchair && (!seat.Wheelchair || seat.Row != before.Row) {
+		if err := tx.Model(&models.Seat{}).Where("companion_of_id = ?", seat.ID).
+			Updates(map[string]interface{}{"companion": false, "companion_of_id": nil}).Error; err != nil {
+			tx.Rollback()
+			return c.Status(500).JSON(fiber.Map{
+				"message": "Failed to update seat",
+				"error":   err.Error(),
+			})
+		}
+	}
+
+	screen := models.Screen{ID: seat.ScreenID}
+	if err := syncLayoutFromSeats(tx, &screen); err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to update seat",
+			"error":   err.Error(),
+		})
+	}
+
+	if err := recordAudit(tx, c, "update", "seat", seat.ID, before, seat); err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to update seat",
+			"error":   err.Error(),
+		})
+	}
+
+	tx.Commit()
+
+	return c.JSON(fiber.Map{
+		"message": "Seat updated successfully",
+		"seat":    seat,
+	})
+}
+
+// DeleteSeat removes a seat from its screen. A seat sold for an upcoming
+// show cannot be removed; past bookings keep pointing at the soft-deleted seat.
+func DeleteSeat(c *fiber.Ctx) error {
+	id := c.Params("id")
+	var seat models.Seat
+
+	if err := database.DB.Scopes(seatsInChain(c)).First(&seat, id).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"message": "Seat not found",
+		})
+	}
+
+	booked, err := seatBookedAhead(database.DB, seat.ID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to delete seat",
+			"error":   err.Error(),
+		})
+	}
+	if booked {
+		return c.Status(409).JSON(fiber.Map{
+			"message": "Seats with bookings for upcoming shows cannot be removed",
+		})
+	}
+
+	tx := database.DB.Begin()
+
+	if err := tx.Delete(&seat).Error; err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to delete seat",
+			"error":   err.Error(),
+		})
+	}
+
+	if err := tx.Model(&models.Seat{}).Where("companion_of_id = ?", seat.ID).
+		Updates(map[string]interface{}{"companion": false, "companion_of_id": nil}).Error; err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to delete seat",
+			"error":   err.Error(),
+		})
+	}
+
+	screen := models.Screen{ID: seat.ScreenID}
+	if err := syncLayoutFromSeats(tx, &screen); err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to delete seat",
+			"error":   err.Error(),
+		})
+	}
+
+	if err := recordAudit(tx, c, "delete", "seat", seat.ID, seat, nil); err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to delete seat",
+			"error":   err.Error(),
+		})
+	}
+
+	tx.Commit()
+
+	return c.JSON(fiber.Map{
+		"message": "Seat deleted successfully",
+	})
+}
@@ -0,0 +1,44 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/SaharKhamseh/cinema-backend/models"
+)
+
+// UpdateTheater answers 403 whenever lockedLicenseField names a field
+func TestLockedLicenseField(t *testing.T) {
+	roleAllows := func(role string) func(string) bool {
+		return func(permission string) bool { return models.RoleAllows(role, permission) }
+	}
+	chainAdmin := func(string) bool { return true }
+
+	tests := []struct {
+		name   string
+		data   map[string]interface{}
+		allows func(string) bool
+		want   string
+	}{
+		{"manager renames", map[string]interface{}{"name": "Azadi"}, roleAllows("manager"), ""},
+		{"manager changes capacity", map[string]interface{}{"name": "Azadi", "capacity": 900.0}, roleAllows("manager"), "capacity"},
+		{"manager changes release time", map[string]interface{}{"accessible_release_minutes": 0.0}, roleAllows("manager"), "accessible_release_minutes"},
+		{"cashier changes capacity", map[string]interface{}{"capacity": 900.0}, roleAllows("cashier"), "capacity"},
+		{"chain admin changes both", map[string]interface{}{"capacity": 900.0, "accessible_release_minutes": 30.0}, chainAdmin, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := lockedLicenseField(tt.data, tt.allows); got != tt.want {
+				t.Errorf("lockedLicenseField = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNoTheaterRoleHoldsTheLicense(t *testing.T) {
+	for role := range models.RolePermissions {
+		if models.RoleAllows(role, models.PermLicenseTheater) {
+			t.Errorf("theater role %q can change the licensed capacity", role)
+		}
+	}
+}
@@ -0,0 +1,310 @@
+package controller
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/SaharKhamseh/cinema-backend/database"
+	"github.com/SaharKhamseh/cinema-backend/models"
+	"github.com/gofiber/fiber/v2"
+)
+
+const earthRadiusKm = 6371.0
+
+// haversineKm is the great-circle distance between two points in kilometres
+func haversineKm(lat1, lng1, lat2, lng2 float64) float64 {
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+
+	dLat := toRad(lat2 - lat1)
+	dLng := toRad(lng2 - lng1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// geoCell is a one degree by one degree square of the theater index
+type geoCell struct {
+	lat, lng int
+}
+
+type geoPoint struct {
+	theaterID uint
+	chainID   uint
+	lat, lng  float64
+}
+
+// theaterIndex buckets theater coordinates by geoCell so a nearby search
+// only measures the theaters in the cells the radius touches. It is built
+// on first use and dropped whenever a theater changes.
+var theaterIndex struct {
+	sync.RWMutex
+	cells map[geoCell][]geoPoint
+}
+
+func cellOf(lat, lng float64) geoCell {
+	return geoCell{int(math.Floor(lat)), int(math.Floor(lng))}
+}
+
+// invalidateTheaterIndex drops the index after theaters were added,
+// moved, deleted or restored
+func invalidateTheaterIndex() {
+	theaterIndex.Lock()
+	theaterIndex.cells = nil
+	theaterIndex.Unlock()
+}
+
+func loadTheaterIndex() (map[geoCell][]geoPoint, error) {
+	theaterIndex.RLock()
+	cells := theaterIndex.cells
+	theaterIndex.RUnlock()
+	if cells != nil {
+		return cells, nil
+	}
+
+	var theaters []models.Theater
+	if err := database.DB.
+		Select("id", "chain_id", "latitude", "longitude").
+		Where("latitude IS NOT NULL AND longitude IS NOT NULL").
+		Find(&theaters).Error; err != nil {
+		return nil, err
+	}
+
+	cells = map[geoCell][]geoPoint{}
+	for _, theater := range theaters {
+		point := geoPoint{theater.ID, theater.ChainID, *theater.Latitude, *theater.Longitude}
+		cell := cellOf(point.lat, point.lng)
+		cells[cell] = append(cells[cell], point)
+	}
+
+	theaterIndex.Lock()
+	theaterIndex.cells = cells
+	theaterIndex.Unlock()
+	return cells, nil
+}
+
+// theatersWithin returns the ids of the chain's theaters within radiusKm,
+// nearest first, with their distances
+func theatersWithin(chainID uint, lat, lng, radiusKm float64) ([]uint, map[uint]float64, error) {
+	cells, err := loadTheaterIndex()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// A degree of latitude is about 111 km, a degree of longitude shrinks
+	// towards the poles
+	latSpan := int(math.Ceil(radiusKm / 111))
+	lngSpan := 180
+	if cos := math.Cos(lat * math.Pi / 180); cos > 0.01 {
+		lngSpan = int(math.Min(180, math.Ceil(radiusKm/(111*cos))))
+	}
+
+	center := cellOf(lat, lng)
+	distances := map[uint]float64{}
+	seen := map[geoCell]bool{}
+	for dLat := -latSpan; dLat <= latSpan; dLat++ {
+		for dLng := -lngSpan; dLng <= lngSpan; dLng++ {
+			// Wrap around the antimeridian
+			cell := geoCell{center.lat + dLat, ((center.lng+dLng+180)%360+360)%360 - 180}
+			if seen[cell] {
+				continue
+			}
+			seen[cell] = true
+
+			for _, point := range cells[cell] {
+				if point.chainID != chainID {
+					continue
+				}
+				if distance := haversineKm(lat, lng, point.lat, point.lng); distance <= radiusKm {
+					distances[point.theaterID] = distance
+				}
+			}
+		}
+	}
+
+	ids := make([]uint, 0, len(distances))
+	for id := range distances {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if distances[ids[i]] != distances[ids[j]] {
+			return distances[ids[i]] < distances[ids[j]]
+		}
+		return ids[i] < ids[j]
+	})
+	return ids, distances, nil
+}
+
+// applyTheaterDetails sets the address, coordinates, contact details,
+// opening hours and amenities present in a request body
+func applyTheaterDetails(theater *models.Theater, data map[string]interface{}) error {
+	for field, target := range map[string]*string{
+		"address":     &theater.Address,
+		"city":        &theater.City,
+		"postal_code": &theater.PostalCode,
+		"phone":       &theater.Phone,
+		"email":       &theater.Email,
+		"website":     &theater.Website,
+	} {
+		if value, ok := data[field]; ok {
+			text, isString := value.(string)
+			if value != nil && !isString {
+				return fmt.Errorf("%s must be text", field)
+			}
+			*target = strings.TrimSpace(text)
+		}
+	}
+
+	_, hasLat := data["latitude"]
+	_, hasLng := data["longitude"]
+	if hasLat || hasLng {
+		if data["latitude"] == nil && data["longitude"] == nil {
+			theater.Latitude, theater.Longitude = nil, nil
+		} else {
+			lat, latOK := data["latitude"].(float64)
+			lng, lngOK := data["longitude"].(float64)
+			if !latOK || !lngOK || lat < -90 || lat > 90 || lng < -180 || lng > 180 {
+				return fmt.Errorf("latitude and longitude must be given together as valid coordinates")
+			}
+			theater.Latitude, theater.Longitude = &lat, &lng
+		}
+	}
+
+	if value, ok := data["opening_hours"]; ok {
+		if value == nil {
+			theater.OpeningHours = []models.OpeningHours{}
+		} else {
+			hours, err := parseOpeningHours(value)
+			if err != nil {
+				return err
+			}
+			theater.OpeningHours = hours
+		}
+	}
+
+	if value, ok := data["amenities"]; ok {
+		items, isList := value.([]interface{})
+		if value != nil && !isList {
+			return fmt.Errorf("amenities must be a list")
+		}
+		amenities := []string{}
+		for _, item := range items {
+			amenity, isString := item.(string)
+			if !isString {
+				return fmt.Errorf("amenities must be a list")
+			}
+			if amenity = strings.ToLower(strings.TrimSpace(amenity)); amenity != "" {
+				amenities = append(amenities, amenity)
+			}
+		}
+		theater.Amenities = amenities
+	}
+
+	return nil
+}
+
+// NearbyTheater is a theater in a nearby search with its distance and the
+// next shows on any of its screens
+type NearbyTheater struct {
+	models.Theater
+	DistanceKm    float64           `json:"distance_km"`
+	NextShowTimes []models.ShowTime `json:"next_showtimes"`
+}
+
+// GetNearbyTheaters lists theaters within ?radius= km (default 25) of
+// ?lat=&lng=, nearest first, each with its next few show times
+func GetNearbyTheaters(c *fiber.Ctx) error {
+	lat, latErr := parseFloatQuery(c, "lat")
+	lng, lngErr := parseFloatQuery(c, "lng")
+	if latErr != nil || lngErr != nil || lat < -90 || lat > 90 || lng < -180 || lng > 180 {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "lat and lng are required coordinates",
+		})
+	}
+
+	radius := 25.0
+	if c.Query("radius") != "" {
+		var err error
+		if radius, err = parseFloatQuery(c, "radius"); err != nil || radius <= 0 || radius > 1000 {
+			return c.Status(400).JSON(fiber.Map{
+				"message": "radius must be between 0 and 1000 km",
+			})
+		}
+	}
+
+	limit := c.QueryInt("limit", 20)
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	showLimit := c.QueryInt("showtimes", 3)
+	if showLimit < 0 || showLimit > 20 {
+		showLimit = 3
+	}
+
+	ids, distances, err := theatersWithin(chainID(c), lat, lng, radius)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to search theaters",
+			"error":   err.Error(),
+		})
+	}
+	if len(ids) > limit {
+		ids = ids[:limit]
+	}
+
+	results := make([]NearbyTheater, 0, len(ids))
+	if len(ids) == 0 {
+		return c.JSON(results)
+	}
+
+	var theaters []models.Theater
+	if err := database.DB.Scopes(inChain(c)).Where("id IN ?", ids).Find(&theaters).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to search theaters",
+			"error":   err.Error(),
+		})
+	}
+	byID := map[uint]models.Theater{}
+	for _, theater := range theaters {
+		byID[theater.ID] = theater
+	}
+
+	var movies []*models.Movie
+	for _, id := range ids {
+		theater, ok := byID[id]
+		if !ok {
+			continue
+		}
+
+		result := NearbyTheater{Theater: theater, DistanceKm: math.Round(distances[id]*100) / 100, NextShowTimes: []models.ShowTime{}}
+		if showLimit > 0 {
+			database.DB.
+				Preload("Movie").
+				Preload("Screen").
+				Preload("Format").
+				Where("screen_id IN (SELECT id FROM screens WHERE theater_id = ? AND deleted_at IS NULL)", id).
+				Where("start_time > ?", time.Now()).
+				Order("start_time").
+				Limit(showLimit).
+				Find(&result.NextShowTimes)
+		}
+		results = append(results, result)
+	}
+
+	for i := range results {
+		for j := range results[i].NextShowTimes {
+			movies = append(movies, &results[i].NextShowTimes[j].Movie)
+		}
+	}
+	localizeMovies(c, movies...)
+
+	return c.JSON(results)
+}
+
+func parseFloatQuery(c *fiber.Ctx, key string) (float64, error) {
+	return strconv.ParseFloat(c.Query(key), 64)
+}
@@ -0,0 +1,98 @@
+package controller
+
+import (
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/SaharKhamseh/cinema-backend/models"
+)
+
+func TestHaversineKm(t *testing.T) {
+	tests := []struct {
+		name                   string
+		lat1, lng1, lat2, lng2 float64
+		want                   float64
+	}{
+		{"same point", 35.6892, 51.3890, 35.6892, 51.3890, 0},
+		{"Tehran to Isfahan", 35.6892, 51.3890, 32.6546, 51.6680, 338},
+		{"across the antimeridian", 0, 179.5, 0, -179.5, 111},
+		{"pole to pole", 90, 0, -90, 0, 20015},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := haversineKm(tt.lat1, tt.lng1, tt.lat2, tt.lng2)
+			if math.Abs(got-tt.want) > 1 {
+				t.Errorf("haversineKm = %.1f, want about %.0f", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCellOf(t *testing.T) {
+	tests := []struct {
+		lat, lng float64
+		want     geoCell
+	}{
+		{35.7, 51.4, geoCell{35, 51}},
+		{-33.9, -70.6, geoCell{-34, -71}},
+		{0, 0, geoCell{0, 0}},
+	}
+
+	for _, tt := range tests {
+		if got := cellOf(tt.lat, tt.lng); got != tt.want {
+			t.Errorf("cellOf(%v, %v) = %v, want %v", tt.lat, tt.lng, got, tt.want)
+		}
+	}
+}
+
+func TestApplyTheaterDetails(t *testing.T) {
+	t.Run("sets and trims details", func(t *testing.T) {
+		var theater models.Theater
+		err := applyTheaterDetails(&theater, map[string]interface{}{
+			"city":      "  Tehran ",
+			"latitude":  35.7,
+			"longitude": 51.4,
+			"amenities": []interface{}{" Parking", "", "CAFE"},
+		})
+		if err != nil {
+			t.Fatalf("applyTheaterDetails failed: %v", err)
+		}
+		if theater.City != "Tehran" || theater.Latitude == nil || *theater.Latitude != 35.7 ||
+			!slices.Equal(theater.Amenities, []string{"parking", "cafe"}) {
+			t.Errorf("unexpected theater %+v", theater)
+		}
+	})
+
+	t.Run("null coordinates clear the location", func(t *testing.T) {
+		lat, lng := 1.0, 2.0
+		theater := models.Theater{Latitude: &lat, Longitude: &lng}
+		if err := applyTheaterDetails(&theater, map[string]interface{}{"latitude": nil, "longitude": nil}); err != nil {
+			t.Fatalf("applyTheaterDetails failed: %v", err)
+		}
+		if theater.Latitude != nil || theater.Longitude != nil {
+			t.Error("coordinates should be cleared")
+		}
+	})
+
+	errors := []struct {
+		name string
+		data map[string]interface{}
+	}{
+		{"latitude alone", map[string]interface{}{"latitude": 35.7}},
+		{"latitude out of range", map[string]interface{}{"latitude": 91.0, "longitude": 0.0}},
+		{"longitude out of range", map[string]interface{}{"latitude": 0.0, "longitude": -181.0}},
+		{"phone not text", map[string]interface{}{"phone": 12345.0}},
+		{"amenities not a list", map[string]interface{}{"amenities": "parking"}},
+		{"amenity not text", map[string]interface{}{"amenities": []interface{}{1.0}}},
+	}
+	for _, tt := range errors {
+		t.Run(tt.name, func(t *testing.T) {
+			var theater models.Theater
+			if err := applyTheaterDetails(&theater, tt.data); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
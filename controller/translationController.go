@@ -0,0 +1,197 @@
+package controller
+
+import (
+	"strings"
+
+	"github.com/SaharKhamseh/cinema-backend/database"
+	"github.com/SaharKhamseh/cinema-backend/i18n"
+	"github.com/SaharKhamseh/cinema-backend/models"
+	"github.com/gofiber/fiber/v2"
+)
+
+// localeOf returns the locale negotiated by middleware.Localize
+func localeOf(c *fiber.Ctx) string {
+	if locale, ok := c.Locals("locale").(string); ok && locale != "" {
+		return locale
+	}
+	return i18n.DefaultLocale
+}
+
+// localizeMovies replaces title and description with the best translation
+// for the request locale. The most specific locale wins (fa-ir before fa);
+// fields a translation leaves empty keep the original text, which is in
+// the default locale.
+func localizeMovies(c *fiber.Ctx, movies ...*models.Movie) {
+	chain := i18n.Fallbacks(localeOf(c))
+	if len(chain) <= 1 || len(movies) == 0 {
+		return
+	}
+	chain = chain[:len(chain)-1] // the default locale is the movie itself
+
+	ids := make([]uint, 0, len(movies))
+	for _, movie := range movies {
+		ids = append(ids, movie.ID)
+	}
+
+	var translations []models.MovieTranslation
+	database.DB.Where("movie_id IN ? AND locale IN ?", ids, chain).Find(&translations)
+	if len(translations) == 0 {
+		return
+	}
+
+	byLocale := map[uint]map[string]models.MovieTranslation{}
+	for _, translation := range translations {
+		if byLocale[translation.MovieID] == nil {
+			byLocale[translation.MovieID] = map[string]models.MovieTranslation{}
+		}
+		byLocale[translation.MovieID][translation.Locale] = translation
+	}
+
+	for _, movie := range movies {
+		// Least specific first, so more specific fields overwrite them
+		for i := len(chain) - 1; i >= 0; i-- {
+			translation, ok := byLocale[movie.ID][chain[i]]
+			if !ok {
+				continue
+			}
+			if translation.Title != "" {
+				movie.Title = translation.Title
+			}
+			if translation.Description != "" {
+				movie.Description = translation.Description
+			}
+		}
+	}
+}
+
+// GetMovieTranslations lists every translation of a movie
+func GetMovieTranslations(c *fiber.Ctx) error {
+	id := c.Params("id")
+	var translations []models.MovieTranslation
+
+	if err := database.DB.Scopes(movieDataInChain(c)).Where("movie_id = ?", id).Order("locale").Find(&translations).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to fetch translations",
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(translations)
+}
+
+// SaveMovieTranslation creates or replaces the translation of a movie for a locale
+func SaveMovieTranslation(c *fiber.Ctx) error {
+	id := c.Params("id")
+	var movie models.Movie
+
+	if err := database.DB.Scopes(inChain(c)).First(&movie, id).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"message": "Movie not found",
+		})
+	}
+
+	// Regional variants are allowed as long as their language is supported
+	locale := i18n.Normalize(c.Params("locale"))
+	supported := false
+	for _, candidate := range i18n.Fallbacks(locale) {
+		if candidate != i18n.DefaultLocale && i18n.IsSupported(candidate) {
+			supported = true
+		}
+	}
+	if !supported {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Unsupported locale",
+		})
+	}
+
+	var data map[string]interface{}
+	if err := c.BodyParser(&data); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Invalid request body",
+		})
+	}
+
+	title, _ := data["title"].(string)
+	description, _ := data["description"].(string)
+	if strings.TrimSpace(title) == "" && strings.TrimSpace(description) == "" {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "title is required",
+		})
+	}
+
+	var translation models.MovieTranslation
+	var before interface{}
+	action := "create"
+	if err := database.DB.Where("movie_id = ? AND locale = ?", movie.ID, locale).First(&translation).Error; err == nil {
+		before = translation
+		action = "update"
+	}
+
+	translation.MovieID = movie.ID
+	translation.Locale = locale
+	translation.Title = strings.TrimSpace(title)
+	translation.Description = strings.TrimSpace(description)
+
+	tx := database.DB.Begin()
+
+	if err := tx.Save(&translation).Error; err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to save translation",
+			"error":   err.Error(),
+		})
+	}
+
+	if err := recordAudit(tx, c, action, "movie_translation", translation.ID, before, translation); err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to save translation",
+			"error":   err.Error(),
+		})
+	}
+
+	tx.Commit()
+
+	return c.JSON(fiber.Map{
+		"message":     "Translation saved successfully",
+		"translation": translation,
+	})
+}
+
+// DeleteMovieTranslation removes the translation of a movie for a locale
+func DeleteMovieTranslation(c *fiber.Ctx) error {
+	var translation models.MovieTranslation
+
+	if err := database.DB.
+		Scopes(movieDataInChain(c)).
+		Where("movie_id = ? AND locale = ?", c.Params("id"), i18n.Normalize(c.Params("locale"))).
+		First(&translation).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"message": "Translation not found",
+		})
+	}
+
+	tx := database.DB.Begin()
+
+	if err := tx.Delete(&translation).Error; err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to delete translation",
+			"error":   err.Error(),
+		})
+	}
+
+	if err := recordAudit(tx, c, "delete", "movie_translation", translation.ID, translation, nil); err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to delete translation",
+			"error":   err.Error(),
+		})
+	}
+
+	tx.Commit()
+
+	return c.JSON(fiber.Map{
+		"message": "Translation deleted successfully",
+	})
+}
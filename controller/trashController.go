@@ -0,0 +1,232 @@
+package controller
+
+import (
+	"time"
+
+	"github.com/SaharKhamseh/cinema-backend/database"
+	"github.com/SaharKhamseh/cinema-backend/models"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// deletionImpact counts what deleting a record would leave behind
+type deletionImpact struct {
+	FutureShowTimes []uint `json:"-"`
+	ShowTimes       int    `json:"future_showtimes"`
+	ActiveBookings  int64  `json:"active_bookings"`
+}
+
+func (i deletionImpact) blocking() bool {
+	return i.ShowTimes > 0 || i.ActiveBookings > 0
+}
+
+// futureShowTimeImpact finds the shows matching the condition that have not
+// started yet, and the bookings on them that are still active
+func futureShowTimeImpact(query string, args ...interface{}) (deletionImpact, error) {
+	var impact deletionImpact
+
+	if err := database.DB.Model(&models.ShowTime{}).
+		Where(query, args...).
+		Where("start_time > ?", time.Now()).
+		Pluck("id", &impact.FutureShowTimes).Error; err != nil {
+		return impact, err
+	}
+	impact.ShowTimes = len(impact.FutureShowTimes)
+
+	if impact.ShowTimes > 0 {
+		if err := database.DB.Model(&models.Booking{}).
+			Where("show_time_id IN ? AND status != ?", impact.FutureShowTimes, "cancelled").
+			Count(&impact.ActiveBookings).Error; err != nil {
+			return impact, err
+		}
+	}
+
+	return impact, nil
+}
+
+// blockedDeletion answers 409 with the impact so the client can retry with ?cascade=true
+func blockedDeletion(c *fiber.Ctx, impact deletionImpact) error {
+	return c.Status(409).JSON(fiber.Map{
+		"message": "Cannot delete while future show times or active bookings exist. Pass cascade=true to cancel them",
+		"impact":  impact,
+	})
+}
+
+// cascadeShowTimes cancels the active bookings of the given shows and
+// soft-deletes the shows, auditing each one
+func cascadeShowTimes(tx *gorm.DB, c *fiber.Ctx, showTimeIDs []uint) error {
+	if len(showTimeIDs) == 0 {
+		return nil
+	}
+
+	if err := tx.Model(&models.Booking{}).
+		Where("show_time_id IN ? AND status != ?", showTimeIDs, "cancelled").
+		Updates(bookingCancellation()).Error; err != nil {
+		return err
+	}
+
+	var showTimes []models.ShowTime
+	if err := tx.Where("id IN ?", showTimeIDs).Find(&showTimes).Error; err != nil {
+		return err
+	}
+
+	for _, showTime := range showTimes {
+		if err := tx.Delete(&showTime).Error; err != nil {
+			return err
+		}
+		if err := recordAudit(tx, c, "delete", "showtime", showTime.ID, showTime, nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// trashModels maps the :type route parameter to the soft-deletable models
+var trashModels = map[string]func() interface{}{
+	"movies":    func() interface{} { return &[]models.Movie{} },
+	"showtimes": func() interface{} { return &[]models.ShowTime{} },
+	"theaters":  func() interface{} { return &[]models.Theater{} },
+	"screens":   func() interface{} { return &[]models.Screen{} },
+}
+
+// trashScopes limits each type of deleted record to the request's chain
+var trashScopes = map[string]func(*fiber.Ctx) func(*gorm.DB) *gorm.DB{
+	"movies":    inChain,
+	"showtimes": showTimesInChain,
+	"theaters":  inChain,
+	"screens":   screensInChain,
+}
+
+// GetDeletedRecords lists soft-deleted movies, showtimes, theaters or screens
+func GetDeletedRecords(c *fiber.Ctx) error {
+	newList, ok := trashModels[c.Params("type")]
+	if !ok {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Unknown type. Use movies, showtimes, theaters or screens",
+		})
+	}
+
+	records := newList()
+	query := database.DB.Unscoped().Model(records).Scopes(trashScopes[c.Params("type")](c)).Where("deleted_at IS NOT NULL")
+
+	sortFields := map[string]string{"deleted_at": "deleted_at", "id": "id"}
+	meta, err := paginateOffset(c, query, records, sortFields, "deleted_at DESC")
+	if err != nil {
+		return pageErrorResponse(c, err, "Failed to fetch deleted records")
+	}
+
+	return c.JSON(pageEnvelope(records, meta))
+}
+
+// RestoreDeletedRecord brings back a soft-deleted record. A record cannot be
+// restored while its parent (movie, screen or theater) is still deleted.
+func RestoreDeletedRecord(c *fiber.Ctx) error {
+	id := c.Params("id")
+	kind := c.Params("type")
+
+	var record interface{}
+	var entityType string
+	var entityID uint
+
+	switch kind {
+	case "movies":
+		var movie models.Movie
+		if err := database.DB.Unscoped().Scopes(inChain(c)).Where("deleted_at IS NOT NULL").First(&movie, id).Error; err != nil {
+			return c.Status(404).JSON(fiber.Map{
+				"message": "Deleted movie not found",
+			})
+		}
+		record, entityType, entityID = &movie, "movie", movie.ID
+
+	case "showtimes":
+		var showTime models.ShowTime
+		if err := database.DB.Unscoped().Scopes(showTimesInChain(c)).Where("deleted_at IS NOT NULL").First(&showTime, id).Error; err != nil {
+			return c.Status(404).JSON(fiber.Map{
+				"message": "Deleted show time not found",
+			})
+		}
+		if err := database.DB.First(&models.Movie{}, showTime.MovieID).Error; err != nil {
+			return c.Status(409).JSON(fiber.Map{
+				"message": "Restore the movie of this show time first",
+			})
+		}
+		var screen models.Screen
+		if err := database.DB.First(&screen, showTime.ScreenID).Error; err != nil {
+			return c.Status(409).JSON(fiber.Map{
+				"message": "Restore the screen of this show time first",
+			})
+		}
+		if err := checkSchedule(screen, showTime.StartTime, showTime.EndTime, showTime.ID); err != nil {
+			if _, ok := err.(scheduleError); ok {
+				return c.Status(409).JSON(fiber.Map{
+					"message": err.Error(),
+				})
+			}
+			return scheduleErrorResponse(c, err)
+		}
+		record, entityType, entityID = &showTime, "showtime", showTime.ID
+
+	case "theaters":
+		var theater models.Theater
+		if err := database.DB.Unscoped().Scopes(inChain(c)).Where("deleted_at IS NOT NULL").First(&theater, id).Error; err != nil {
+			return c.Status(404).JSON(fiber.Map{
+				"message": "Deleted theater not found",
+			})
+		}
+		record, entityType, entityID = &theater, "theater", theater.ID
+
+	case "screens":
+		var screen models.Screen
+		if err := database.DB.Unscoped().Scopes(screensInChain(c)).Where("deleted_at IS NOT NULL").First(&screen, id).Error; err != nil {
+			return c.Status(404).JSON(fiber.Map{
+				"message": "Deleted screen not found",
+			})
+		}
+		if err := database.DB.First(&models.Theater{}, screen.TheaterID).Error; err != nil {
+			return c.Status(409).JSON(fiber.Map{
+				"message": "Restore the theater of this screen first",
+			})
+		}
+		var seats int64
+		database.DB.Model(&models.Seat{}).Where("screen_id = ?", screen.ID).Count(&seats)
+		if err := checkLicensedCapacity(database.DB, screen, int(seats)); err != nil {
+			return layoutErrorResponse(c, err, "Failed to restore record")
+		}
+		record, entityType, entityID = &screen, "screen", screen.ID
+
+	default:
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Unknown type. Use movies, showtimes, theaters or screens",
+		})
+	}
+
+	tx := database.DB.Begin()
+
+	if err := tx.Unscoped().Model(record).Update("deleted_at", nil).Error; err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to restore record",
+			"error":   err.Error(),
+		})
+	}
+
+	if err := recordAudit(tx, c, "restore", entityType, entityID, nil, record); err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to restore record",
+			"error":   err.Error(),
+		})
+	}
+
+	tx.Commit()
+
+	if entityType == "theater" {
+		invalidateTheaterIndex()
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Record restored successfully",
+		"record":  record,
+	})
+}
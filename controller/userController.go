@@ -0,0 +1,61 @@
+package controller
+
+import (
+	"github.com/SaharKhamseh/cinema-backend/database"
+	"github.com/SaharKhamseh/cinema-backend/models"
+	"github.com/gofiber/fiber/v2"
+)
+
+// SetAccessibleSeating records whether a customer needs accessible seating.
+// Only staff set it, so held wheelchair spaces cannot be claimed by anyone
+// who asks for them.
+func SetAccessibleSeating(c *fiber.Ctx) error {
+	var user models.User
+	if err := database.DB.Scopes(inChain(c)).First(&user, c.Params("id")).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"message": "User not found",
+		})
+	}
+
+	var data map[string]interface{}
+	if err := c.BodyParser(&data); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Invalid request body",
+		})
+	}
+
+	accessible, ok := data["accessible_seating"].(bool)
+	if !ok {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "accessible_seating must be true or false",
+		})
+	}
+
+	before := user
+	user.AccessibleSeating = accessible
+
+	tx := database.DB.Begin()
+
+	if err := tx.Model(&user).Update("accessible_seating", accessible).Error; err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to update user",
+			"error":   err.Error(),
+		})
+	}
+
+	if err := recordAudit(tx, c, "update", "user", user.Id, before, user); err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to update user",
+			"error":   err.Error(),
+		})
+	}
+
+	tx.Commit()
+
+	return c.JSON(fiber.Map{
+		"message": "User updated successfully",
+		"user":    user,
+	})
+}
@@ -0,0 +1,309 @@
+package controller
+
+import (
+	"time"
+
+	"github.com/SaharKhamseh/cinema-backend/database"
+	"github.com/SaharKhamseh/cinema-backend/models"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// GetWatchlist returns the movies the logged-in user follows
+func GetWatchlist(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{
+			"message": "Unauthorized",
+		})
+	}
+
+	var items []models.WatchlistItem
+	if err := database.DB.
+		Preload("Movie").
+		Joins("JOIN movies ON movies.id = watchlist_items.movie_id AND movies.deleted_at IS NULL").
+		Where("watchlist_items.user_id = ?", userID).
+		Order("watchlist_items.created_at DESC").
+		Find(&items).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to fetch watchlist",
+			"error":   err.Error(),
+		})
+	}
+
+	movies := make([]*models.Movie, len(items))
+	for i := range items {
+		movies[i] = &items[i].Movie
+	}
+	localizeMovies(c, movies...)
+
+	return c.JSON(items)
+}
+
+// AddToWatchlist follows a movie
+func AddToWatchlist(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{
+			"message": "Unauthorized",
+		})
+	}
+
+	var data map[string]interface{}
+	if err := c.BodyParser(&data); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Invalid request body",
+		})
+	}
+
+	if data["movie_id"] == nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "movie_id is required",
+		})
+	}
+
+	var movie models.Movie
+	if err := database.DB.Scopes(inChain(c)).First(&movie, data["movie_id"]).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"message": "Movie not found",
+		})
+	}
+
+	item := models.WatchlistItem{UserID: userID, MovieID: movie.ID}
+	if err := database.DB.
+		Where(models.WatchlistItem{UserID: userID, MovieID: movie.ID}).
+		FirstOrCreate(&item).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to update watchlist",
+			"error":   err.Error(),
+		})
+	}
+
+	return c.Status(201).JSON(fiber.Map{
+		"message": "Movie added to watchlist",
+		"item":    item,
+	})
+}
+
+// RemoveFromWatchlist stops following a movie
+func RemoveFromWatchlist(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{
+			"message": "Unauthorized",
+		})
+	}
+
+	result := database.DB.
+		Where("user_id = ? AND movie_id = ?", userID, c.Params("movieId")).
+		Delete(&models.WatchlistItem{})
+	if result.Error != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to update watchlist",
+			"error":   result.Error.Error(),
+		})
+	}
+	if result.RowsAffected == 0 {
+		return c.Status(404).JSON(fiber.Map{
+			"message": "Movie is not on your watchlist",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Movie removed from watchlist",
+	})
+}
+
+// GetPreferredTheaters returns the theaters the user wants alerts for
+func GetPreferredTheaters(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{
+			"message": "Unauthorized",
+		})
+	}
+
+	var preferences []models.PreferredTheater
+	if err := database.DB.Preload("Theater").Where("user_id = ?", userID).Find(&preferences).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to fetch preferred theaters",
+			"error":   err.Error(),
+		})
+	}
+
+	theaters := make([]models.Theater, 0, len(preferences))
+	for _, preference := range preferences {
+		if preference.Theater.ID != 0 {
+			theaters = append(theaters, preference.Theater)
+		}
+	}
+
+	return c.JSON(theaters)
+}
+
+// SetPreferredTheaters replaces the theaters the user wants alerts for. An
+// empty theater_ids list means every theater.
+func SetPreferredTheaters(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{
+			"message": "Unauthorized",
+		})
+	}
+
+	var data map[string]interface{}
+	if err := c.BodyParser(&data); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "Invalid request body",
+		})
+	}
+
+	if data["theater_ids"] == nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "theater_ids is required",
+		})
+	}
+
+	ids, err := idList(data["theater_ids"])
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+	ids = uniqueIDs(ids)
+
+	var found int64
+	if len(ids) > 0 {
+		database.DB.Model(&models.Theater{}).Scopes(inChain(c)).Where("id IN ?", ids).Count(&found)
+	}
+	if int(found) != len(ids) {
+		return c.Status(400).JSON(fiber.Map{
+			"message": "one or more theaters do not exist",
+		})
+	}
+
+	tx := database.DB.Begin()
+
+	if err := tx.Where("user_id = ?", userID).Delete(&models.PreferredTheater{}).Error; err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to update preferred theaters",
+			"error":   err.Error(),
+		})
+	}
+
+	for _, id := range ids {
+		if err := tx.Create(&models.PreferredTheater{UserID: userID, TheaterID: id}).Error; err != nil {
+			tx.Rollback()
+			return c.Status(500).JSON(fiber.Map{
+				"message": "Failed to update preferred theaters",
+				"error":   err.Error(),
+			})
+		}
+	}
+
+	tx.Commit()
+
+	return c.JSON(fiber.Map{
+		"message":     "Preferred theaters updated successfully",
+		"theater_ids": ids,
+	})
+}
+
+// notifyWatchers adds a new show time to today's digest of every user who
+// watches its movie, unless they prefer other theaters. It runs inside the
+// transaction that creates the show time.
+func notifyWatchers(tx *gorm.DB, showTime models.ShowTime, theaterID uint) error {
+	var userIDs []uint
+	if err := tx.Model(&models.WatchlistItem{}).
+		Where("movie_id = ?", showTime.MovieID).
+		Where(`NOT EXISTS (SELECT 1 FROM preferred_theaters p WHERE p.user_id = watchlist_items.user_id)
+			OR EXISTS (SELECT 1 FROM preferred_theaters p WHERE p.user_id = watchlist_items.user_id AND p.theater_id = ?)`,
+			theaterID).
+		Pluck("user_id", &userIDs).Error; err != nil {
+		return err
+	}
+
+	day := time.Now().Format("2006-01-02")
+	for _, userID := range userIDs {
+		notification := models.Notification{UserID: userID, Day: day, Kind: "showtimes_open"}
+		if err := tx.
+			Where(models.Notification{UserID: userID, Day: day, Kind: "showtimes_open"}).
+			FirstOrCreate(&notification).Error; err != nil {
+			return err
+		}
+
+		// A digest that was already read becomes unread again
+		if err := tx.Model(&notification).Update("read_at", nil).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Exec("INSERT INTO notification_show_times (notification_id, show_time_id) VALUES (?, ?)",
+			notification.ID, showTime.ID).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetNotifications returns the logged-in user's notifications, newest first.
+// Pass ?unread=true for unread ones only.
+func GetNotifications(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{
+			"message": "Unauthorized",
+		})
+	}
+
+	query := database.DB.Model(&models.Notification{}).Where("user_id = ?", userID)
+	if c.QueryBool("unread") {
+		query = query.Where("read_at IS NULL")
+	}
+
+	var notifications []models.Notification
+	meta, err := paginateOffset(c, query, &notifications, map[string]string{"day": "day"}, "day DESC",
+		preload("ShowTimes.Movie", "ShowTimes.Screen", "ShowTimes.Format"))
+	if err != nil {
+		return pageErrorResponse(c, err, "Failed to fetch notifications")
+	}
+
+	var movies []*models.Movie
+	for i := range notifications {
+		for j := range notifications[i].ShowTimes {
+			movies = append(movies, &notifications[i].ShowTimes[j].Movie)
+		}
+	}
+	localizeMovies(c, movies...)
+
+	return c.JSON(pageEnvelope(notifications, meta))
+}
+
+// MarkNotificationRead marks one of the user's notifications as read
+func MarkNotificationRead(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{
+			"message": "Unauthorized",
+		})
+	}
+
+	var notification models.Notification
+	if err := database.DB.Where("user_id = ?", userID).First(&notification, c.Params("id")).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"message": "Notification not found",
+		})
+	}
+
+	now := time.Now()
+	notification.ReadAt = &now
+	if err := database.DB.Model(&notification).Update("read_at", now).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"message": "Failed to update notification",
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(notification)
+}
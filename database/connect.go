@@ -27,12 +27,34 @@ func Connect() {
 	DB = database
 
 	DB.AutoMigrate(
+		&models.Chain{},
 		&models.User{},
+		&models.Genre{},
+		&models.Tag{},
 		&models.Movie{},
+		&models.Person{},
+		&models.Credit{},
+		&models.MediaAsset{},
+		&models.MovieTranslation{},
+		&models.Format{},
 		&models.Theater{},
 		&models.Screen{},
 		&models.Seat{},
 		&models.ShowTime{},
 		&models.Booking{},
+		&models.Review{},
+		&models.AuditLog{},
+		&models.Recommendation{},
+		&models.WatchlistItem{},
+		&models.PreferredTheater{},
+		&models.Notification{},
+		&models.RoleAssignment{},
+		&models.SeatBlock{},
 	)
+
+	migrateChains()
+	migrateGenres()
+	migrateFormats()
+	migrateAudioLanguages()
+	migrateRefunds()
 }
@@ -0,0 +1,159 @@
+package database
+
+import (
+	"log"
+
+	"github.com/SaharKhamseh/cinema-backend/models"
+	"github.com/SaharKhamseh/cinema-backend/util"
+)
+
+// DefaultChainID is the chain that requests fall back to when neither the
+// host name nor the logged-in user names one. It is the chain that existed
+// before chains were introduced.
+var DefaultChainID uint
+
+// chainOwned lists the tables with a chain_id column, along with the
+// unique indexes that became per chain when chains were introduced
+var chainOwned = []struct {
+	model    interface{}
+	oldIndex string
+}{
+	{&models.User{}, ""},
+	{&models.Theater{}, ""},
+	{&models.Movie{}, "idx_movies_external_id"},
+	{&models.Genre{}, "idx_genres_slug"},
+	{&models.Tag{}, "idx_tags_slug"},
+	{&models.Person{}, ""},
+	{&models.Format{}, "idx_formats_code"},
+	{&models.AuditLog{}, ""},
+}
+
+// migrateChains creates the default chain on first start and hands it every
+// record that was created before chains existed. AutoMigrate adds the
+// chain_id columns as nullable, so those records hold NULL rather than 0.
+func migrateChains() {
+	chain := models.Chain{Name: "Default", Slug: "default"}
+	if err := DB.Order("id").FirstOrCreate(&chain).Error; err != nil {
+		log.Fatal("Could not create the default chain: ", err)
+	}
+	DefaultChainID = chain.ID
+
+	for _, owned := range chainOwned {
+		if owned.oldIndex != "" && DB.Migrator().HasIndex(owned.model, owned.oldIndex) {
+			if err := DB.Migrator().DropIndex(owned.model, owned.oldIndex); err != nil {
+				log.Println("Chain migration could not drop", owned.oldIndex, err)
+			}
+		}
+		if err := DB.Unscoped().Model(owned.model).Where("chain_id = 0 OR chain_id IS NULL").Update("chain_id", chain.ID).Error; err != nil {
+			log.Println("Chain migration failed:", err)
+		}
+	}
+}
+
+// migrateGenres links movies that only have the legacy free-text genre to
+// Genre rows. Values such as "Action/Comedy" or "action, comedy" are split
+// and matched by slug, creating missing genres on the way.
+func migrateGenres() {
+	var movies []models.Movie
+	if err := DB.Preload("Genres").Where("genre <> ?", "").Find(&movies).Error; err != nil {
+		log.Println("Genre migration skipped:", err)
+		return
+	}
+
+	for _, movie := range movies {
+		if len(movie.Genres) > 0 {
+			continue
+		}
+
+		var genres []models.Genre
+		for _, name := range util.SplitGenres(movie.Genre) {
+			slug := util.Slugify(name)
+			if slug == "" {
+				continue
+			}
+
+			genre := models.Genre{ChainID: movie.ChainID, Name: name, Slug: slug}
+			if err := DB.Where(models.Genre{ChainID: movie.ChainID, Slug: slug}).FirstOrCreate(&genre).Error; err != nil {
+				log.Println("Genre migration failed for movie", movie.ID, err)
+				continue
+			}
+			genres = append(genres, genre)
+		}
+
+		if len(genres) > 0 {
+			DB.Model(&movie).Association("Genres").Replace(genres)
+		}
+	}
+}
+
+// defaultFormats are created on first start so existing screens and shows
+// have a format to point at
+var defaultFormats = []models.Format{
+	{Code: "2d", Name: "2D"},
+	{Code: "3d", Name: "3D", Surcharge: 2},
+	{Code: "imax", Name: "IMAX", Surcharge: 5},
+	{Code: "dolby-atmos", Name: "Dolby Atmos", Surcharge: 3},
+}
+
+// SeedFormats creates the default formats a chain does not have yet
+func SeedFormats(chainID uint) error {
+	for _, format := range defaultFormats {
+		format.ChainID = chainID
+		if err := DB.Where(models.Format{ChainID: chainID, Code: format.Code}).FirstOrCreate(&format).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrateFormats seeds the default formats of every chain and lets every
+// screen without capabilities project 2D, which is what they did before
+// formats existed
+func migrateFormats() {
+	var chainIDs []uint
+	DB.Model(&models.Chain{}).Pluck("id", &chainIDs)
+
+	for _, chainID := range chainIDs {
+		if err := SeedFormats(chainID); err != nil {
+			log.Println("Format migration skipped:", err)
+			return
+		}
+
+		var standard models.Format
+		if err := DB.Where("chain_id = ? AND code = ?", chainID, "2d").First(&standard).Error; err != nil {
+			continue
+		}
+
+		if err := DB.Exec(`INSERT INTO screen_formats (screen_id, format_id)
+			SELECT screens.id, ? FROM screens JOIN theaters ON theaters.id = screens.theater_id
+			WHERE theaters.chain_id = ?
+			AND NOT EXISTS (SELECT 1 FROM screen_formats WHERE screen_formats.screen_id = screens.id)`,
+			standard.ID, chainID).Error; err != nil {
+			log.Println("Format migration failed:", err)
+		}
+
+		DB.Unscoped().Model(&models.ShowTime{}).
+			Where("format_id IS NULL").
+			Where("movie_id IN (SELECT id FROM movies WHERE chain_id = ?)", chainID).
+			Update("format_id", standard.ID)
+	}
+}
+
+// migrateAudioLanguages gives shows scheduled before screening versions
+// existed the original language of their movie
+func migrateAudioLanguages() {
+	if err := DB.Exec(`UPDATE show_times SET audio_language =
+		(SELECT language FROM movies WHERE movies.id = show_times.movie_id)
+		WHERE audio_language = '' OR audio_language IS NULL`).Error; err != nil {
+		log.Println("Audio language migration failed:", err)
+	}
+}
+
+// migrateRefunds records the refund of bookings cancelled before refunds
+// were tracked. Cancelling always refunded the whole price.
+func migrateRefunds() {
+	if err := DB.Exec(`UPDATE bookings SET refunded_amount = total_price
+		WHERE status = 'cancelled' AND (refunded_amount = 0 OR refunded_amount IS NULL)`).Error; err != nil {
+		log.Println("Refund migration failed:", err)
+	}
+}
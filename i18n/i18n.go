@@ -0,0 +1,201 @@
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultLocale is the language the API and the movie catalogue are written in
+const DefaultLocale = "en"
+
+//go:embed locales/*.json
+var files embed.FS
+
+// catalogue maps English messages to one locale. Keys containing "{}"
+// match any text in that position, which is carried over to the
+// translation, e.g. "{} is required" covers "title is required".
+type catalogue struct {
+	exact    map[string]string
+	patterns []pattern
+}
+
+type pattern struct {
+	re          *regexp.Regexp
+	translation string
+}
+
+var catalogues = map[string]*catalogue{}
+
+func init() {
+	entries, err := files.ReadDir("locales")
+	if err != nil {
+		panic(err)
+	}
+
+	for _, entry := range entries {
+		raw, err := files.ReadFile(path.Join("locales", entry.Name()))
+		if err != nil {
+			panic(err)
+		}
+
+		var messages map[string]string
+		if err := json.Unmarshal(raw, &messages); err != nil {
+			panic("invalid locale file " + entry.Name() + ": " + err.Error())
+		}
+
+		catalogues[strings.TrimSuffix(entry.Name(), ".json")] = newCatalogue(messages)
+	}
+}
+
+// newCatalogue splits the messages of a locale file into exact messages and
+// patterns
+func newCatalogue(messages map[string]string) *catalogue {
+	cat := &catalogue{exact: map[string]string{}}
+	for key, translation := range messages {
+		if !strings.Contains(key, "{}") {
+			cat.exact[key] = translation
+			continue
+		}
+		expr := "^" + strings.ReplaceAll(regexp.QuoteMeta(key), `\{\}`, "(.+?)") + "$"
+		cat.patterns = append(cat.patterns, pattern{
+			re:          regexp.MustCompile(expr),
+			translation: translation,
+		})
+	}
+	return cat
+}
+
+// translate looks a message up in the catalogue, filling the placeholders
+// of a matching pattern with the text they matched
+func (cat *catalogue) translate(message string) (string, bool) {
+	if translation, ok := cat.exact[message]; ok {
+		return translation, true
+	}
+
+	for _, p := range cat.patterns {
+		if match := p.re.FindStringSubmatch(message); match != nil {
+			translation := p.translation
+			for _, value := range match[1:] {
+				translation = strings.Replace(translation, "{}", value, 1)
+			}
+			return translation, true
+		}
+	}
+
+	return "", false
+}
+
+// Supported lists the locales the API can answer in
+func Supported() []string {
+	locales := []string{DefaultLocale}
+	for locale := range catalogues {
+		if locale != DefaultLocale {
+			locales = append(locales, locale)
+		}
+	}
+	sort.Strings(locales[1:])
+	return locales
+}
+
+// IsSupported reports whether a normalized locale has a catalogue
+func IsSupported(locale string) bool {
+	_, ok := catalogues[locale]
+	return ok || locale == DefaultLocale
+}
+
+// Normalize lowercases a language tag and uses "-" as separator, so
+// "fa_IR" and "fa-ir" are the same locale
+func Normalize(tag string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(tag), "_", "-"))
+}
+
+// Fallbacks returns the locales to try for a tag, most specific first and
+// always ending with the default locale: "fa-ir" gives fa-ir, fa, en.
+func Fallbacks(tag string) []string {
+	tag = Normalize(tag)
+	var chain []string
+	for tag != "" {
+		chain = append(chain, tag)
+		i := strings.LastIndex(tag, "-")
+		if i < 0 {
+			break
+		}
+		tag = tag[:i]
+	}
+	if len(chain) == 0 || chain[len(chain)-1] != DefaultLocale {
+		chain = append(chain, DefaultLocale)
+	}
+	return chain
+}
+
+// Negotiate picks the best supported locale from an Accept-Language header.
+// Tags are tried by descending quality; a regional tag such as fa-IR
+// falls back to its base language before the next tag is considered.
+func Negotiate(header string) string {
+	type candidate struct {
+		tag     string
+		quality float64
+	}
+
+	var candidates []candidate
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		tag := Normalize(fields[0])
+		if tag == "" || tag == "*" {
+			continue
+		}
+
+		quality := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if q, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					quality = q
+				}
+			}
+		}
+		if quality > 0 {
+			candidates = append(candidates, candidate{tag, quality})
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].quality > candidates[j].quality
+	})
+
+	for _, c := range candidates {
+		for _, locale := range Fallbacks(c.tag) {
+			if locale == DefaultLocale && c.tag != DefaultLocale && !strings.HasPrefix(c.tag, DefaultLocale+"-") {
+				// Only the last resort, keep looking at other tags first
+				break
+			}
+			if IsSupported(locale) {
+				return locale
+			}
+		}
+	}
+
+	return DefaultLocale
+}
+
+// Translate returns message in the given locale, or message unchanged when
+// the locale has no translation for it
+func Translate(locale, message string) string {
+	for _, candidate := range Fallbacks(locale) {
+		cat, ok := catalogues[candidate]
+		if !ok {
+			continue
+		}
+
+		if translation, ok := cat.translate(message); ok {
+			return translation
+		}
+	}
+
+	return message
+}
@@ -0,0 +1,103 @@
+package i18n
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCatalogueTranslate(t *testing.T) {
+	cat := newCatalogue(map[string]string{
+		"Movie not found":                  "فیلم یافت نشد",
+		"{} is required":                   "{} الزامی است",
+		"seat {}{} is blocked ({})":        "صندلی {}{} مسدود است ({})",
+		"Maximum size is {} MB. Try again": "حداکثر اندازه {} مگابایت است. دوباره تلاش کنید",
+	})
+
+	tests := []struct {
+		name    string
+		message string
+		want    string
+		found   bool
+	}{
+		{"exact", "Movie not found", "فیلم یافت نشد", true},
+		{"placeholder", "title is required", "title الزامی است", true},
+		{"placeholder with spaces", "show time id is required", "show time id الزامی است", true},
+		{"several placeholders", "seat A12 is blocked (maintenance)", "صندلی A12 مسدود است (maintenance)", true},
+		{"regexp characters are literal", "Maximum size is 10 MB. Try again", "حداکثر اندازه 10 مگابایت است. دوباره تلاش کنید", true},
+		{"pattern must match whole message", "title is required here", "", false},
+		{"empty placeholder does not match", " is required", "", false},
+		{"unknown", "Theater not found", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, found := cat.translate(tt.message)
+			if found != tt.found || got != tt.want {
+				t.Errorf("translate(%q) = %q, %v; want %q, %v", tt.message, got, found, tt.want, tt.found)
+			}
+		})
+	}
+}
+
+func TestFallbacks(t *testing.T) {
+	tests := []struct {
+		tag  string
+		want []string
+	}{
+		{"fa", []string{"fa", "en"}},
+		{"fa_IR", []string{"fa-ir", "fa", "en"}},
+		{"zh-Hant-TW", []string{"zh-hant-tw", "zh-hant", "zh", "en"}},
+		{"en-GB", []string{"en-gb", "en"}},
+		{"", []string{"en"}},
+	}
+
+	for _, tt := range tests {
+		if got := Fallbacks(tt.tag); !slices.Equal(got, tt.want) {
+			t.Errorf("Fallbacks(%q) = %v, want %v", tt.tag, got, tt.want)
+		}
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"", "en"},
+		{"fa", "fa"},
+		{"fa-IR,fa;q=0.9,en;q=0.8", "fa"},
+		{"en-US,en;q=0.9,fa;q=0.8", "en"},
+		{"de-DE,fa;q=0.5", "fa"},
+		{"fa;q=0, en", "en"},
+		{"en;q=0.3, fa;q=0.7", "fa"},
+		{"*", "en"},
+		{"de, fr;q=0.8", "en"},
+	}
+
+	for _, tt := range tests {
+		if got := Negotiate(tt.header); got != tt.want {
+			t.Errorf("Negotiate(%q) = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestTranslateFallsBackToMessage(t *testing.T) {
+	if got := Translate("en", "Movie not found"); got != "Movie not found" {
+		t.Errorf("Translate(en) = %q, want the message unchanged", got)
+	}
+	if got := Translate("fa", "a message nobody translated"); got != "a message nobody translated" {
+		t.Errorf("Translate(fa) = %q, want the message unchanged", got)
+	}
+}
+
+func TestLocaleFilesKeepPlaceholders(t *testing.T) {
+	for locale, cat := range catalogues {
+		for _, p := range cat.patterns {
+			keys := strings.Count(p.re.String(), "(.+?)")
+			if got := strings.Count(p.translation, "{}"); got != keys {
+				t.Errorf("%s: %q has %d placeholders, its key has %d", locale, p.translation, got, keys)
+			}
+		}
+	}
+}
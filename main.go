@@ -1,21 +1,32 @@
 package main
 
 import (
+	"os"
+
+	"github.com/SaharKhamseh/cinema-backend/database"
+	"github.com/SaharKhamseh/cinema-backend/routes"
+	"github.com/SaharKhamseh/cinema-backend/storage"
 	"github.com/gofiber/fiber/v2"
-	"github.com/gofiber/fiber/v2/middleware/cors"
 )
 
 func main() {
-	app := fiber.New()
+	database.Connect()
+
+	// Anything after the program name is a maintenance command, see commands.go
+	if len(os.Args) > 1 {
+		os.Exit(runCommand(os.Args[1], os.Args[2:]))
+	}
+
+	storage.Setup()
 
-	app.Use(cors.New(cors.Config{
-		AllowOrigins:     "http://localhost:3000",
-		AllowHeaders:     "Origin, Content-Type, Accept",
-		AllowCredentials: true,
-		AllowMethods:     "GET,POST,PUT,DELETE",
-	}))
+	app := fiber.New(fiber.Config{
+		// Bodies over the limit are streamed rather than refused, so trailer
+		// uploads go to temporary files. middleware.LimitBody keeps the
+		// other routes to the limit.
+		StreamRequestBody: true,
+	})
 
-	// Your routes here
+	routes.Setup(app)
 
 	app.Listen(":8000")
 }
@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"io"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// LimitBody answers 413 to requests whose body is larger than max bytes.
+// The server streams large bodies instead of refusing them, so trailers are
+// never held in memory whole; this keeps every other route to the usual
+// limit. Requests for which skip returns true check their own limit.
+func LimitBody(max int, skip func(*fiber.Ctx) bool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if skip(c) {
+			return c.Next()
+		}
+
+		tooLarge := c.Request().Header.ContentLength() > max
+		if !tooLarge && c.Request().IsBodyStream() {
+			body, err := io.ReadAll(io.LimitReader(c.Request().BodyStream(), int64(max)+1))
+			if err != nil {
+				return c.Status(400).JSON(fiber.Map{
+					"message": "Unable to read request body",
+				})
+			}
+			tooLarge = len(body) > max
+			c.Request().SetBody(body)
+		}
+
+		if tooLarge {
+			return c.Status(413).JSON(fiber.Map{
+				"message": "Request body is too large",
+			})
+		}
+
+		return c.Next()
+	}
+}
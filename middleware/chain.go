@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"net"
+	"strings"
+
+	"github.com/SaharKhamseh/cinema-backend/database"
+	"github.com/SaharKhamseh/cinema-backend/models"
+	"github.com/SaharKhamseh/cinema-backend/util"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ResolveChain works out which chain a request belongs to and stores its id
+// in c.Locals("chain_id"). A host name registered to a chain wins, then the
+// chain of the logged-in user, then the default chain. Users of one chain
+// cannot use their token on another chain's host, except superadmins.
+func ResolveChain(c *fiber.Ctx) error {
+	var chainID uint
+
+	host := strings.ToLower(c.Hostname())
+	if name, _, err := net.SplitHostPort(host); err == nil {
+		host = name
+	}
+	var chain models.Chain
+	if err := database.DB.Where("domain = ?", host).First(&chain).Error; err == nil {
+		chainID = chain.ID
+	}
+
+	if id, err := util.Parsejwt(c.Cookies("jwt")); err == nil {
+		var user models.User
+		if err := database.DB.First(&user, id).Error; err == nil {
+			if chainID == 0 {
+				chainID = user.ChainID
+			} else if user.ChainID != chainID && user.Role != "superadmin" {
+				return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+					"message": "Access Denied",
+				})
+			}
+		}
+	}
+
+	if chainID == 0 {
+		chainID = database.DefaultChainID
+	}
+	c.Locals("chain_id", chainID)
+
+	return c.Next()
+}
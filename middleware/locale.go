@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/SaharKhamseh/cinema-backend/i18n"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Localize negotiates the response language from Accept-Language, or from
+// ?lang= when given, and stores it in c.Locals("locale"). After the handler
+// runs, the "message" field of JSON responses is translated so controllers
+// can keep writing English messages.
+func Localize(c *fiber.Ctx) error {
+	locale := i18n.Negotiate(c.Get(fiber.HeaderAcceptLanguage))
+	if lang := i18n.Normalize(c.Query("lang")); lang != "" && i18n.IsSupported(lang) {
+		locale = lang
+	}
+
+	c.Locals("locale", locale)
+	c.Set(fiber.HeaderContentLanguage, locale)
+	c.Vary(fiber.HeaderAcceptLanguage)
+
+	err := c.Next()
+
+	if locale != i18n.DefaultLocale {
+		translateMessage(c, locale)
+	}
+
+	return err
+}
+
+func translateMessage(c *fiber.Ctx, locale string) {
+	if !strings.HasPrefix(string(c.Response().Header.ContentType()), fiber.MIMEApplicationJSON) {
+		return
+	}
+
+	body := c.Response().Body()
+	if !bytes.Contains(body, []byte(`"message"`)) {
+		return
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var payload map[string]interface{}
+	if err := decoder.Decode(&payload); err != nil {
+		return
+	}
+
+	message, ok := payload["message"].(string)
+	if !ok {
+		return
+	}
+
+	translated := i18n.Translate(locale, message)
+	if translated == message {
+		return
+	}
+	payload["message"] = translated
+
+	if raw, err := json.Marshal(payload); err == nil {
+		c.Response().SetBodyRaw(raw)
+	}
+}
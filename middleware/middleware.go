@@ -37,7 +37,32 @@ func IsAdmin(c *fiber.Ctx) error {
 	var user models.User
 	database.DB.First(&user, userId)
 
-	if user.Role != "admin" {
+	if user.Role != "admin" && user.Role != "superadmin" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"message": "Access Denied",
+		})
+	}
+	return c.Next()
+}
+
+// IsSuperAdmin lets through the operators of the deployment, who manage
+// the chains themselves
+func IsSuperAdmin(c *fiber.Ctx) error {
+	cookie := c.Cookies("jwt")
+
+	id, err := util.Parsejwt(cookie)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"message": "Unauthorized",
+		})
+	}
+
+	userId, _ := strconv.Atoi(id)
+
+	var user models.User
+	database.DB.First(&user, userId)
+
+	if user.Role != "superadmin" {
 		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
 			"message": "Access Denied",
 		})
@@ -0,0 +1,156 @@
+package middleware
+
+import (
+	"strconv"
+
+	"github.com/SaharKhamseh/cinema-backend/database"
+	"github.com/SaharKhamseh/cinema-backend/models"
+	"github.com/SaharKhamseh/cinema-backend/util"
+	"github.com/gofiber/fiber/v2"
+)
+
+// TheaterResolver finds the theater that owns the resource a request
+// touches, e.g. the theater of the screen in the :id parameter
+type TheaterResolver func(c *fiber.Ctx) (uint, error)
+
+// HasTheaterPermission reports whether a user may do something at a
+// theater, either as a chain admin or through a role assigned there
+func HasTheaterPermission(userID, theaterID uint, permission string) bool {
+	var user models.User
+	if err := database.DB.First(&user, userID).Error; err != nil {
+		return false
+	}
+	if user.Role == "admin" || user.Role == "superadmin" {
+		return true
+	}
+
+	var roles []string
+	database.DB.Model(&models.RoleAssignment{}).
+		Where("user_id = ? AND theater_id = ?", userID, theaterID).
+		Pluck("role", &roles)
+	for _, role := range roles {
+		if models.RoleAllows(role, permission) {
+			return true
+		}
+	}
+	return false
+}
+
+// Can lets a request through when the user holds permission at the theater
+// found by theaterOf. Chain admins are let through without a lookup.
+func Can(permission string, theaterOf TheaterResolver) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, err := util.Parsejwt(c.Cookies("jwt"))
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"message": "Unauthorized",
+			})
+		}
+		userId, _ := strconv.Atoi(id)
+
+		var user models.User
+		database.DB.First(&user, userId)
+		if user.Role == "admin" || user.Role == "superadmin" {
+			return c.Next()
+		}
+
+		theaterID, err := theaterOf(c)
+		if err != nil || !HasTheaterPermission(user.Id, theaterID, permission) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"message": "Access Denied",
+			})
+		}
+		return c.Next()
+	}
+}
+
+func paramID(c *fiber.Ctx) (uint, error) {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	return uint(id), err
+}
+
+// TheaterParam resolves the theater in the :id parameter
+func TheaterParam(c *fiber.Ctx) (uint, error) {
+	return paramID(c)
+}
+
+// ScreenParam resolves the theater of the screen in the :id parameter
+func ScreenParam(c *fiber.Ctx) (uint, error) {
+	id, err := paramID(c)
+	if err != nil {
+		return 0, err
+	}
+	return theaterOfScreen(id)
+}
+
+// SeatParam resolves the theater of the seat in the :id parameter
+func SeatParam(c *fiber.Ctx) (uint, error) {
+	id, err := paramID(c)
+	if err != nil {
+		return 0, err
+	}
+
+	var seat models.Seat
+	if err := database.DB.Select("screen_id").First(&seat, id).Error; err != nil {
+		return 0, err
+	}
+	return theaterOfScreen(seat.ScreenID)
+}
+
+// ShowTimeParam resolves the theater of the show time in the :id parameter
+func ShowTimeParam(c *fiber.Ctx) (uint, error) {
+	id, err := paramID(c)
+	if err != nil {
+		return 0, err
+	}
+
+	var showTime models.ShowTime
+	if err := database.DB.Select("screen_id").First(&showTime, id).Error; err != nil {
+		return 0, err
+	}
+	return theaterOfScreen(showTime.ScreenID)
+}
+
+// SeatBlockParam resolves the theater of the seat block in the :id parameter
+func SeatBlockParam(c *fiber.Ctx) (uint, error) {
+	id, err := paramID(c)
+	if err != nil {
+		return 0, err
+	}
+
+	var block models.SeatBlock
+	if err := database.DB.Select("screen_id").First(&block, id).Error; err != nil {
+		return 0, err
+	}
+	return theaterOfScreen(block.ScreenID)
+}
+
+// BodyTheater resolves the theater_id of a JSON request body
+func BodyTheater(c *fiber.Ctx) (uint, error) {
+	var data struct {
+		TheaterID uint `json:"theater_id"`
+	}
+	if err := c.BodyParser(&data); err != nil {
+		return 0, err
+	}
+	return data.TheaterID, nil
+}
+
+// BodyScreen resolves the theater of the screen_id of a JSON request body
+func BodyScreen(c *fiber.Ctx) (uint, error) {
+	var data struct {
+		ScreenID uint `json:"screen_id"`
+	}
+	if err := c.BodyParser(&data); err != nil {
+		return 0, err
+	}
+	return theaterOfScreen(data.ScreenID)
+}
+
+func theaterOfScreen(screenID uint) (uint, error) {
+	var screen models.Screen
+	if err := database.DB.Select("theater_id").First(&screen, screenID).Error; err != nil {
+		return 0, err
+	}
+	return screen.TheaterID, nil
+}
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+)
+
+type AuditLog struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	ChainID    uint      `json:"chain_id" gorm:"index"`
+	ActorID    uint      `json:"actor_id" gorm:"index"`
+	Action     string    `json:"action" gorm:"not null"` // create, update, delete
+	EntityType string    `json:"entity_type" gorm:"not null;index:idx_audit_entity"`
+	EntityID   uint      `json:"entity_id" gorm:"index:idx_audit_entity"`
+	Before     string    `json:"before" gorm:"type:text"` // JSON snapshot
+	After      string    `json:"after" gorm:"type:text"`  // JSON snapshot
+	Diff       string    `json:"diff" gorm:"type:text"`   // JSON map of field -> {from, to}
+	CreatedAt  time.Time `json:"created_at"`
+}
@@ -0,0 +1,16 @@
+package models
+
+import (
+	"time"
+)
+
+// Chain is a cinema brand sharing this deployment. Theaters, movies,
+// formats, the movie catalogue and users belong to exactly one chain, and
+// requests only ever see the data of the chain they were resolved to.
+type Chain struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Name      string    `json:"name" gorm:"not null"`
+	Slug      string    `json:"slug" gorm:"size:100;uniqueIndex;not null"`
+	Domain    *string   `json:"domain" gorm:"size:255;uniqueIndex"` // host name the chain's site is served on
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,11 @@
+package models
+
+// Format is a projection format such as 2D, 3D, IMAX or Dolby Atmos.
+// Surcharge is added to the ticket price of every show in this format.
+type Format struct {
+	ID        uint    `json:"id" gorm:"primaryKey"`
+	ChainID   uint    `json:"chain_id" gorm:"uniqueIndex:idx_format_chain_code"`
+	Code      string  `json:"code" gorm:"size:50;uniqueIndex:idx_format_chain_code;not null"`
+	Name      string  `json:"name" gorm:"not null"`
+	Surcharge float64 `json:"surcharge"`
+}
@@ -0,0 +1,15 @@
+package models
+
+type Genre struct {
+	ID      uint   `json:"id" gorm:"primaryKey"`
+	ChainID uint   `json:"chain_id" gorm:"uniqueIndex:idx_genre_chain_slug"`
+	Name    string `json:"name" gorm:"not null"`
+	Slug    string `json:"slug" gorm:"size:100;uniqueIndex:idx_genre_chain_slug;not null"`
+}
+
+type Tag struct {
+	ID      uint   `json:"id" gorm:"primaryKey"`
+	ChainID uint   `json:"chain_id" gorm:"uniqueIndex:idx_tag_chain_slug"`
+	Name    string `json:"name" gorm:"not null"`
+	Slug    string `json:"slug" gorm:"size:100;uniqueIndex:idx_tag_chain_slug;not null"`
+}
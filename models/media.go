@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+)
+
+type MediaAsset struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	MovieID     uint      `json:"movie_id" gorm:"index"`
+	Kind        string    `json:"kind" gorm:"not null"`    // poster, backdrop, trailer
+	Variant     string    `json:"variant" gorm:"not null"` // original, small, medium, large
+	StorageKey  string    `json:"-" gorm:"not null"`
+	URL         string    `json:"url" gorm:"not null"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"` // in bytes
+	Width       int       `json:"width,omitempty"`
+	Height      int       `json:"height,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
@@ -2,17 +2,30 @@ package models
 
 import (
 	"time"
+
+	"gorm.io/gorm"
 )
 
 type Movie struct {
-	ID          uint      `json:"id" gorm:"primaryKey"`
-	Title       string    `json:"title" gorm:"not null"`
-	Description string    `json:"description"`
-	Duration    int       `json:"duration" gorm:"not null"` // in minutes
-	Genre       string    `json:"genre"`
-	Language    string    `json:"language"`
-	ReleaseDate time.Time `json:"release_date"`
-	PosterURL   string    `json:"poster_url"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID            uint           `json:"id" gorm:"primaryKey"`
+	ChainID       uint           `json:"chain_id" gorm:"uniqueIndex:idx_movie_chain_external"`
+	ExternalID    *string        `json:"external_id" gorm:"size:64;uniqueIndex:idx_movie_chain_external"` // id in the distributor's catalogue, used by imports
+	Title         string         `json:"title" gorm:"not null"`
+	Description   string         `json:"description"`
+	Duration      int            `json:"duration" gorm:"not null"` // in minutes
+	Genre         string         `json:"genre"`                    // legacy display string, kept in sync with Genres
+	Genres        []Genre        `json:"genres" gorm:"many2many:movie_genres;"`
+	Tags          []Tag          `json:"tags" gorm:"many2many:movie_tags;"`
+	Credits       []Credit       `json:"credits,omitempty" gorm:"foreignKey:MovieID"`
+	Language      string         `json:"language"`
+	ReleaseDate   time.Time      `json:"release_date"`
+	AverageRating float64        `json:"average_rating"`
+	RatingCount   int            `json:"rating_count"`
+	PosterURL     string         `json:"poster_url"`
+	BackdropURL   string         `json:"backdrop_url"`
+	TrailerURL    string         `json:"trailer_url"`
+	Media         []MediaAsset   `json:"media,omitempty" gorm:"foreignKey:MovieID"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `json:"deleted_at" gorm:"index"`
 }
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+)
+
+type Person struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	ChainID   uint      `json:"chain_id" gorm:"index"`
+	Name      string    `json:"name" gorm:"not null"`
+	Biography string    `json:"biography"`
+	PhotoURL  string    `json:"photo_url"`
+	Credits   []Credit  `json:"credits,omitempty" gorm:"foreignKey:PersonID"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type Credit struct {
+	ID            uint    `json:"id" gorm:"primaryKey"`
+	MovieID       uint    `json:"movie_id" gorm:"index"`
+	Movie         *Movie  `json:"movie,omitempty" gorm:"foreignKey:MovieID"`
+	PersonID      uint    `json:"person_id" gorm:"index"`
+	Person        *Person `json:"person,omitempty" gorm:"foreignKey:PersonID"`
+	Role          string  `json:"role" gorm:"not null"` // e.g., director, actor, writer, producer
+	CharacterName string  `json:"character_name"`
+	BillingOrder  int     `json:"billing_order"`
+}
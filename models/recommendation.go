@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// Recommendation is one cached, precomputed movie suggestion for a user.
+// BecauseMovieID is the watched movie behind a "because you watched" row.
+type Recommendation struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	UserID         uint      `json:"user_id" gorm:"index;not null"`
+	MovieID        uint      `json:"movie_id" gorm:"not null"`
+	Movie          Movie     `json:"movie" gorm:"foreignKey:MovieID"`
+	Rank           int       `json:"rank"`
+	Score          float64   `json:"score"`
+	Reason         string    `json:"reason"` // co_attendance, genre, language, popular
+	BecauseMovieID *uint     `json:"because_movie_id"`
+	BecauseMovie   *Movie    `json:"because_movie,omitempty" gorm:"foreignKey:BecauseMovieID"`
+	ComputedAt     time.Time `json:"computed_at"`
+}
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+)
+
+type Review struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	MovieID     uint       `json:"movie_id" gorm:"uniqueIndex:idx_review_movie_user"`
+	UserID      uint       `json:"user_id" gorm:"uniqueIndex:idx_review_movie_user"`
+	User        User       `json:"user" gorm:"foreignKey:UserID"`
+	Rating      int        `json:"rating" gorm:"not null"` // 1 to 5
+	Text        string     `json:"text" gorm:"type:text"`
+	Status      string     `json:"status" gorm:"default:published"` // published, hidden
+	ReportCount int        `json:"report_count"`
+	ModeratedBy uint       `json:"moderated_by,omitempty"`
+	ModeratedAt *time.Time `json:"moderated_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
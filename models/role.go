@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+)
+
+// Permissions that theater roles grant on the theater they are assigned to
+const (
+	PermManageTheater   = "theater.manage"   // details, contact and opening hours
+	PermManageScreens   = "screens.manage"   // screens, seat layouts, seats and formats
+	PermManageShowTimes = "showtimes.manage" // scheduling, moving and cancelling shows
+	PermSellTickets     = "tickets.sell"     // booking seats for walk-in customers
+	PermViewBookings    = "bookings.view"    // looking up and checking tickets
+	PermCancelBookings  = "bookings.cancel"
+
+	// PermLicenseTheater covers the licensed capacity and the accessible seat
+	// release time. No theater role grants it, so only chain admins hold it.
+	PermLicenseTheater = "theater.license"
+)
+
+// RolePermissions is the permission set of each theater role
+var RolePermissions = map[string][]string{
+	"manager": {PermManageTheater, PermManageScreens, PermManageShowTimes, PermSellTickets, PermViewBookings, PermCancelBookings},
+	"cashier": {PermSellTickets, PermViewBookings, PermCancelBookings},
+	"usher":   {PermViewBookings},
+}
+
+// RoleAllows reports whether a theater role includes a permission
+func RoleAllows(role, permission string) bool {
+	for _, granted := range RolePermissions[role] {
+		if granted == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// RoleAssignment gives a user a role at one theater. Chain admins
+// (User.Role "admin") keep full access to every theater of their chain.
+type RoleAssignment struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"user_id" gorm:"uniqueIndex:idx_role_user_theater"`
+	User      *User     `json:"user,omitempty" gorm:"foreignKey:UserID"`
+	TheaterID uint      `json:"theater_id" gorm:"uniqueIndex:idx_role_user_theater;index"`
+	Role      string    `json:"role" gorm:"size:20;uniqueIndex:idx_role_user_theater;not null"` // manager, cashier, usher
+	GrantedBy uint      `json:"granted_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
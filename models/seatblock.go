@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+)
+
+// SeatBlock takes seats off sale, because they are broken or held for
+// house guests, VIPs or press. A block covers one show when ShowTimeID is
+// set, shows overlapping StartsAt to EndsAt when those are set, and every
+// show otherwise, until it is released.
+type SeatBlock struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	ScreenID   uint       `json:"screen_id" gorm:"index"`
+	Seats      []Seat     `json:"seats" gorm:"many2many:seat_block_seats"`
+	Reason     string     `json:"reason" gorm:"size:20;not null"` // maintenance, house, vip, press
+	Note       string     `json:"note"`
+	ShowTimeID *uint      `json:"show_time_id" gorm:"index"`
+	StartsAt   *time.Time `json:"starts_at"`
+	EndsAt     *time.Time `json:"ends_at"`
+	BlockedBy  uint       `json:"blocked_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ReleasedAt *time.Time `json:"released_at" gorm:"index"`
+	ReleasedBy *uint      `json:"released_by"`
+}
@@ -2,15 +2,26 @@ package models
 
 import (
 	"time"
+
+	"gorm.io/gorm"
 )
 
 type ShowTime struct {
-	ID        uint      `json:"id" gorm:"primaryKey"`
-	MovieID   uint      `json:"movie_id"`
-	Movie     Movie     `json:"movie" gorm:"foreignKey:MovieID"`
-	ScreenID  uint      `json:"screen_id"`
-	Screen    Screen    `json:"screen" gorm:"foreignKey:ScreenID"`
-	StartTime time.Time `json:"start_time"`
-	EndTime   time.Time `json:"end_time"`
-	Price     float64   `json:"price"`
+	ID               uint           `json:"id" gorm:"primaryKey"`
+	MovieID          uint           `json:"movie_id"`
+	Movie            Movie          `json:"movie" gorm:"foreignKey:MovieID"`
+	ScreenID         uint           `json:"screen_id"`
+	Screen           Screen         `json:"screen" gorm:"foreignKey:ScreenID"`
+	StartTime        time.Time      `json:"start_time"`
+	EndTime          time.Time      `json:"end_time"` // after the pre-roll and the feature
+	PreRollMinutes   int            `json:"pre_roll_minutes"`
+	Price            float64        `json:"price"`
+	FormatID         *uint          `json:"format_id"`
+	Format           *Format        `json:"format,omitempty" gorm:"foreignKey:FormatID"`
+	Surcharge        float64        `json:"surcharge"` // format surcharge when the show was scheduled
+	AudioLanguage    string         `json:"audio_language"`
+	SubtitleLanguage string         `json:"subtitle_language"` // empty when not subtitled
+	Captioned        bool           `json:"captioned"`         // open captions for deaf and hard of hearing viewers
+	AudioDescribed   bool           `json:"audio_described"`   // narration track for blind and partially sighted viewers
+	DeletedAt        gorm.DeletedAt `json:"deleted_at" gorm:"index"`
 }
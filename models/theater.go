@@ -1,24 +1,61 @@
 package models
 
+import "gorm.io/gorm"
+
 type Theater struct {
-	ID       uint     `json:"id" gorm:"primaryKey"`
-	Name     string   `json:"name" gorm:"not null"`
-	Capacity int      `json:"capacity" gorm:"not null"`
-	Screens  []Screen `json:"screens" gorm:"foreignKey:TheaterID"`
+	ID                       uint           `json:"id" gorm:"primaryKey"`
+	ChainID                  uint           `json:"chain_id" gorm:"index"`
+	Name                     string         `json:"name" gorm:"not null"`
+	Capacity                 int            `json:"capacity" gorm:"not null"` // licensed capacity, the most seats all screens may have together
+	Address                  string         `json:"address"`
+	City                     string         `json:"city" gorm:"index"`
+	PostalCode               string         `json:"postal_code"`
+	Latitude                 *float64       `json:"latitude"`
+	Longitude                *float64       `json:"longitude"`
+	Phone                    string         `json:"phone"`
+	Email                    string         `json:"email"`
+	Website                  string         `json:"website"`
+	Amenities                []string       `json:"amenities" gorm:"serializer:json;type:text"`     // e.g. parking, cafe, bar, lift
+	OpeningHours             []OpeningHours `json:"opening_hours" gorm:"serializer:json;type:text"` // no hours means always open
+	Screens                  []Screen       `json:"screens" gorm:"foreignKey:TheaterID"`
+	AccessibleReleaseMinutes int            `json:"accessible_release_minutes" gorm:"not null"` // minutes before a show when accessible seats go on general sale
+	DeletedAt                gorm.DeletedAt `json:"deleted_at" gorm:"index"`
 }
 
 type Screen struct {
-	ID        uint   `json:"id" gorm:"primaryKey"`
-	Name      string `json:"name" gorm:"not null"`
-	TheaterID uint   `json:"theater_id"`
-	Capacity  int    `json:"capacity" gorm:"not null"`
-	Seats     []Seat `json:"seats" gorm:"foreignKey:ScreenID"`
+	ID                uint           `json:"id" gorm:"primaryKey"`
+	Name              string         `json:"name" gorm:"not null"`
+	TheaterID         uint           `json:"theater_id"`
+	Capacity          int            `json:"capacity" gorm:"not null"` // number of seats, kept in step with the seat rows
+	Seats             []Seat         `json:"seats" gorm:"foreignKey:ScreenID"`
+	Formats           []Format       `json:"formats" gorm:"many2many:screen_formats"`
+	Layout            string         `json:"-" gorm:"type:text"` // JSON seat layout, see GET /api/screens/:id/layout
+	CleaningMinutes   int            `json:"cleaning_minutes"`   // cleaning after every show
+	TurnaroundMinutes int            `json:"turnaround_minutes"` // letting the next audience in
+	PreRollMinutes    int            `json:"pre_roll_minutes"`   // ads and trailers before the feature
+	DeletedAt         gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+}
+
+// OpeningHours is when a theater is open on one weekday (0 is Sunday).
+// A closing time before the opening time is past midnight.
+type OpeningHours struct {
+	Weekday int    `json:"weekday"`
+	Opens   string `json:"opens"`  // HH:MM
+	Closes  string `json:"closes"` // HH:MM
 }
 
 type Seat struct {
-	ID       uint   `json:"id" gorm:"primaryKey"`
-	ScreenID uint   `json:"screen_id"`
-	Row      string `json:"row" gorm:"not null"`
-	Number   int    `json:"number" gorm:"not null"`
-	Category string `json:"category"` // e.g., standard, premium, vip
+	ID            uint           `json:"id" gorm:"primaryKey"`
+	ScreenID      uint           `json:"screen_id"`
+	Row           string         `json:"row" gorm:"not null"`
+	Number        int            `json:"number" gorm:"not null"`
+	Category      string         `json:"category"`        // e.g., standard, premium, vip
+	X             float64        `json:"x"`               // position on the seat map, in seat widths from the left
+	Y             float64        `json:"y"`               // position on the seat map, in rows from the screen
+	Wheelchair    bool           `json:"wheelchair"`      // space for a wheelchair user
+	Companion     bool           `json:"companion"`       // seat for the companion of a wheelchair user
+	CompanionOfID *uint          `json:"companion_of_id"` // the wheelchair space a companion seat belongs to
+	EasyAccess    bool           `json:"easy_access"`     // step-free, extra legroom or aisle seat
+	HearingLoop   bool           `json:"hearing_loop"`    // covered by the induction loop
+	DeletedAt     gorm.DeletedAt `json:"-" gorm:"index"`
 }
@@ -0,0 +1,14 @@
+package models
+
+import (
+	"time"
+)
+
+type MovieTranslation struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	MovieID     uint      `json:"movie_id" gorm:"uniqueIndex:idx_movie_locale"`
+	Locale      string    `json:"locale" gorm:"size:16;uniqueIndex:idx_movie_locale"` // e.g., fa, fa-ir
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
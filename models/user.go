@@ -2,14 +2,17 @@ package models
 
 import "golang.org/x/crypto/bcrypt"
 
+// The Id, name and email keys keep the casing clients have always received.
 type User struct {
-	Id        uint   `json:"id`
-	FirstName string `json:first_name`
-	LastName  string `json:last_name`
-	Email     string `json:email`
-	Password  []byte `json:"-"`
-	Phone     string `json:"phone"`
-	Role      string `json:"role"`
+	Id                uint   `json:"Id"`
+	ChainID           uint   `json:"chain_id" gorm:"index"`
+	FirstName         string `json:"FirstName"`
+	LastName          string `json:"LastName"`
+	Email             string `json:"Email"`
+	Password          []byte `json:"-"`
+	Phone             string `json:"phone"`
+	Role              string `json:"role"`               // user, admin of the user's chain, or superadmin of the deployment
+	AccessibleSeating bool   `json:"accessible_seating"` // set by staff, lets the customer book held accessible seats
 }
 
 func (user *User) SetPassword(password string) {
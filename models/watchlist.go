@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// WatchlistItem is a movie a user follows to hear when tickets go on sale
+type WatchlistItem struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"user_id" gorm:"uniqueIndex:idx_watchlist_user_movie;not null"`
+	MovieID   uint      `json:"movie_id" gorm:"uniqueIndex:idx_watchlist_user_movie;not null"`
+	Movie     Movie     `json:"movie" gorm:"foreignKey:MovieID"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// PreferredTheater limits watchlist alerts to shows at these theaters.
+// A user without preferred theaters hears about every theater.
+type PreferredTheater struct {
+	UserID    uint    `json:"user_id" gorm:"primaryKey"`
+	TheaterID uint    `json:"theater_id" gorm:"primaryKey"`
+	Theater   Theater `json:"theater" gorm:"foreignKey:TheaterID"`
+}
+
+// Notification is a user's daily digest of new show times for watched
+// movies. All shows opened on the same day go into one notification.
+type Notification struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserID    uint       `json:"user_id" gorm:"uniqueIndex:idx_notification_user_day_kind;not null"`
+	Day       string     `json:"day" gorm:"size:10;uniqueIndex:idx_notification_user_day_kind;not null"` // YYYY-MM-DD
+	Kind      string     `json:"kind" gorm:"size:50;uniqueIndex:idx_notification_user_day_kind;not null"`
+	ShowTimes []ShowTime `json:"show_times" gorm:"many2many:notification_show_times"`
+	ReadAt    *time.Time `json:"read_at"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
@@ -1,8 +1,12 @@
 package routes
 
 import (
+	"strings"
+
 	"github.com/SaharKhamseh/cinema-backend/controller"
 	"github.com/SaharKhamseh/cinema-backend/middleware"
+	"github.com/SaharKhamseh/cinema-backend/models"
+	"github.com/SaharKhamseh/cinema-backend/storage"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 )
@@ -15,6 +19,21 @@ func Setup(app *fiber.App) {
 		AllowCredentials: true, // Important for cookies
 	}))
 
+	// Negotiate the response language for every request
+	app.Use(middleware.Localize)
+
+	// Request bodies are held to the usual limit, except media uploads
+	// which are held to the limit of their kind, see controller.mediaRules
+	app.Use(middleware.LimitBody(fiber.DefaultBodyLimit, isMediaUpload))
+
+	// Every request belongs to one cinema chain
+	app.Use(middleware.ResolveChain)
+
+	// Uploaded media is public
+	if local, ok := storage.Media.(*storage.Local); ok && strings.HasPrefix(local.BaseURL, "/") {
+		app.Static(local.BaseURL, local.Root)
+	}
+
 	app.Post("/api/register", controller.Register)
 	app.Post("/api/login", controller.Login)
 
@@ -26,26 +45,134 @@ func Setup(app *fiber.App) {
 	app.Get("/api/movies/:id", controller.GetMovie)
 	app.Put("/api/movies/:id", middleware.IsAdmin, controller.UpdateMovie)
 	app.Delete("/api/movies/:id", middleware.IsAdmin, controller.DeleteMovie)
+	app.Post("/api/movies/import", middleware.IsAdmin, controller.ImportMovieCatalogue)
+	app.Get("/api/movies/:id/translations", controller.GetMovieTranslations)
+	app.Put("/api/movies/:id/translations/:locale", middleware.IsAdmin, controller.SaveMovieTranslation)
+	app.Delete("/api/movies/:id/translations/:locale", middleware.IsAdmin, controller.DeleteMovieTranslation)
+	app.Post("/api/movies/:id/media/:kind", middleware.IsAdmin, controller.UploadMovieMedia)
+	app.Get("/api/movies/:id/poster", controller.GetMoviePoster)
+
+	// Review routes
+	app.Get("/api/movies/:id/reviews", controller.GetMovieReviews)
+	app.Post("/api/movies/:id/reviews", controller.CreateReview)
+	app.Put("/api/reviews/:id", controller.UpdateReview)
+	app.Delete("/api/reviews/:id", controller.DeleteReview)
+	app.Post("/api/reviews/:id/report", controller.ReportReview)
+	app.Get("/api/admin/reviews", middleware.IsAdmin, controller.GetModerationQueue)
+	app.Put("/api/admin/reviews/:id", middleware.IsAdmin, controller.ModerateReview)
+
+	// Cast and crew routes
+	app.Get("/api/people", controller.GetPeople)
+	app.Get("/api/people/:id", controller.GetPerson)
+	app.Post("/api/people", middleware.IsAdmin, controller.CreatePerson)
+	app.Put("/api/people/:id", middleware.IsAdmin, controller.UpdatePerson)
+	app.Delete("/api/people/:id", middleware.IsAdmin, controller.DeletePerson)
+	app.Post("/api/movies/:id/credits", middleware.IsAdmin, controller.CreateCredit)
+	app.Put("/api/credits/:id", middleware.IsAdmin, controller.UpdateCredit)
+	app.Delete("/api/credits/:id", middleware.IsAdmin, controller.DeleteCredit)
+
+	// Genre and tag routes
+	app.Get("/api/genres", controller.GetGenres)
+	app.Post("/api/genres", middleware.IsAdmin, controller.CreateGenre)
+	app.Put("/api/genres/:id", middleware.IsAdmin, controller.UpdateGenre)
+	app.Delete("/api/genres/:id", middleware.IsAdmin, controller.DeleteGenre)
+	app.Get("/api/tags", controller.GetTags)
+	app.Post("/api/tags", middleware.IsAdmin, controller.CreateTag)
+	app.Put("/api/tags/:id", middleware.IsAdmin, controller.UpdateTag)
+	app.Delete("/api/tags/:id", middleware.IsAdmin, controller.DeleteTag)
 
 	// Theater routes
 	app.Post("/api/theaters", middleware.IsAdmin, controller.CreateTheater)
 	app.Get("/api/theaters", controller.GetTheaters)
+	app.Get("/api/theaters/nearby", controller.GetNearbyTheaters)
 	app.Get("/api/theaters/:id", controller.GetTheater)
+	app.Put("/api/theaters/:id", middleware.Can(models.PermManageTheater, middleware.TheaterParam), controller.UpdateTheater)
+	app.Delete("/api/theaters/:id", middleware.IsAdmin, controller.DeleteTheater)
+	app.Post("/api/theaters/:id/merge", middleware.IsAdmin, controller.MergeTheater)
+
+	// Theater staff routes
+	app.Get("/api/theaters/:id/roles", middleware.IsAdmin, controller.GetTheaterRoles)
+	app.Post("/api/theaters/:id/roles", middleware.IsAdmin, controller.GrantTheaterRole)
+	app.Delete("/api/theaters/:id/roles/:roleId", middleware.IsAdmin, controller.RevokeTheaterRole)
+	app.Get("/api/me/roles", controller.GetMyRoles)
+
+	// Customer routes
+	app.Put("/api/users/:id/accessible-seating", middleware.IsAdmin, controller.SetAccessibleSeating)
 
 	// Screen routes
-	app.Post("/api/screens", middleware.IsAdmin, controller.CreateScreen)
+	app.Post("/api/screens", middleware.Can(models.PermManageScreens, middleware.BodyTheater), controller.CreateScreen)
 	app.Get("/api/screens/:id/seats", controller.GetScreenSeats)
+	app.Put("/api/screens/:id", middleware.Can(models.PermManageScreens, middleware.ScreenParam), controller.UpdateScreen)
+	app.Delete("/api/screens/:id", middleware.Can(models.PermManageScreens, middleware.ScreenParam), controller.DeleteScreen)
+	app.Put("/api/screens/:id/formats", middleware.Can(models.PermManageScreens, middleware.ScreenParam), controller.SetScreenFormats)
+	app.Get("/api/screens/:id/layout", controller.GetScreenLayout)
+	app.Put("/api/screens/:id/layout", middleware.Can(models.PermManageScreens, middleware.ScreenParam), controller.SetScreenLayout)
+
+	// Seat block routes
+	app.Get("/api/screens/:id/blocks", middleware.Can(models.PermManageScreens, middleware.ScreenParam), controller.GetSeatBlocks)
+	app.Post("/api/screens/:id/blocks", middleware.Can(models.PermManageScreens, middleware.ScreenParam), controller.CreateSeatBlock)
+	app.Post("/api/seat-blocks/:id/release", middleware.Can(models.PermManageScreens, middleware.SeatBlockParam), controller.ReleaseSeatBlock)
+
+	// Seat routes
+	app.Put("/api/seats/:id", middleware.Can(models.PermManageScreens, middleware.SeatParam), controller.UpdateSeat)
+	app.Delete("/api/seats/:id", middleware.Can(models.PermManageScreens, middleware.SeatParam), controller.DeleteSeat)
+
+	// Format routes
+	app.Get("/api/formats", controller.GetFormats)
+	app.Post("/api/formats", middleware.IsAdmin, controller.CreateFormat)
+	app.Put("/api/formats/:id", middleware.IsAdmin, controller.UpdateFormat)
+	app.Delete("/api/formats/:id", middleware.IsAdmin, controller.DeleteFormat)
 
 	// ShowTime routes
-	app.Post("/api/showtimes", middleware.IsAdmin, controller.CreateShowTime)
+	app.Post("/api/showtimes", middleware.Can(models.PermManageShowTimes, middleware.BodyScreen), controller.CreateShowTime)
 	app.Get("/api/showtimes", controller.GetShowTimes)
 	app.Get("/api/showtimes/:id", controller.GetShowTime)
-	app.Put("/api/showtimes/:id", middleware.IsAdmin, controller.UpdateShowTime)
-	app.Delete("/api/showtimes/:id", middleware.IsAdmin, controller.DeleteShowTime)
+	app.Get("/api/showtimes/:id/seats", controller.GetShowTimeSeats)
+	app.Put("/api/showtimes/:id", middleware.Can(models.PermManageShowTimes, middleware.ShowTimeParam), controller.UpdateShowTime)
+	app.Delete("/api/showtimes/:id", middleware.Can(models.PermManageShowTimes, middleware.ShowTimeParam), controller.DeleteShowTime)
+	app.Get("/api/showtimes/:id/bookings", middleware.Can(models.PermViewBookings, middleware.ShowTimeParam), controller.GetShowTimeBookings)
 
 	// Booking routes
 	app.Post("/api/bookings", middleware.IsAuthentication, controller.CreateBooking)
 	app.Get("/api/bookings", middleware.IsAuthentication, controller.GetUserBookings)
 	app.Get("/api/bookings/:id", middleware.IsAuthentication, controller.GetBooking)
 	app.Post("/api/bookings/:id/cancel", middleware.IsAuthentication, controller.CancelBooking)
+
+	// Recommendation routes
+	app.Get("/api/me/recommendations", controller.GetMyRecommendations)
+
+	// Watchlist and notification routes
+	app.Get("/api/me/watchlist", controller.GetWatchlist)
+	app.Post("/api/me/watchlist", controller.AddToWatchlist)
+	app.Delete("/api/me/watchlist/:movieId", controller.RemoveFromWatchlist)
+	app.Get("/api/me/preferred-theaters", controller.GetPreferredTheaters)
+	app.Put("/api/me/preferred-theaters", controller.SetPreferredTheaters)
+	app.Get("/api/me/notifications", controller.GetNotifications)
+	app.Post("/api/me/notifications/:id/read", controller.MarkNotificationRead)
+
+	// Report routes
+	app.Get("/api/reports/sales/:dimension", middleware.IsAdmin, controller.GetSalesReport)
+	app.Get("/api/reports/occupancy", middleware.IsAdmin, controller.GetShowTimeOccupancy)
+	app.Get("/api/reports/occupancy/:dimension", middleware.IsAdmin, controller.GetOccupancyReport)
+
+	// Integrity routes
+	app.Get("/api/admin/integrity/capacity", middleware.IsAdmin, controller.GetCapacityReport)
+
+	// Audit routes
+	app.Get("/api/admin/audit", middleware.IsAdmin, controller.GetAuditLogs)
+
+	// Chain routes, for the operators of the deployment
+	app.Get("/api/chains", middleware.IsSuperAdmin, controller.GetChains)
+	app.Post("/api/chains", middleware.IsSuperAdmin, controller.CreateChain)
+	app.Put("/api/chains/:id", middleware.IsSuperAdmin, controller.UpdateChain)
+
+	// Deleted record routes
+	app.Get("/api/admin/trash/:type", middleware.IsAdmin, controller.GetDeletedRecords)
+	app.Post("/api/admin/trash/:type/:id/restore", middleware.IsAdmin, controller.RestoreDeletedRecord)
+}
+
+// isMediaUpload reports whether the request is a movie media upload
+func isMediaUpload(c *fiber.Ctx) bool {
+	path := c.Path()
+	return c.Method() == fiber.MethodPost && strings.HasPrefix(path, "/api/movies/") && strings.Contains(path, "/media/")
 }
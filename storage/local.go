@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Local stores media on the server's filesystem
+type Local struct {
+	Root    string
+	BaseURL string
+}
+
+func NewLocal(root, baseURL string) *Local {
+	return &Local{
+		Root:    root,
+		BaseURL: strings.TrimRight(baseURL, "/"),
+	}
+}
+
+// path resolves key inside Root and refuses keys that would escape it
+func (l *Local) path(key string) (string, error) {
+	clean := path.Clean("/" + key)
+	if clean == "/" {
+		return "", fmt.Errorf("invalid storage key %q", key)
+	}
+	return filepath.Join(l.Root, filepath.FromSlash(clean)), nil
+}
+
+func (l *Local) Save(key string, content io.Reader) (string, error) {
+	target, err := l.path(key)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return "", err
+	}
+
+	file, err := os.Create(target)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := io.Copy(file, content); err != nil {
+		file.Close()
+		os.Remove(target)
+		return "", err
+	}
+
+	if err := file.Close(); err != nil {
+		return "", err
+	}
+
+	return l.URL(key), nil
+}
+
+func (l *Local) Delete(key string) error {
+	target, err := l.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(target); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (l *Local) URL(key string) string {
+	return l.BaseURL + path.Clean("/"+key)
+}
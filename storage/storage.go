@@ -0,0 +1,34 @@
+package storage
+
+import (
+	"io"
+	"os"
+)
+
+// Storage keeps uploaded media files and knows the public URL of each one
+type Storage interface {
+	// Save writes the content under key and returns its public URL
+	Save(key string, content io.Reader) (string, error)
+	// Delete removes the file stored under key, if any
+	Delete(key string) error
+	// URL returns the public URL of key
+	URL(key string) string
+}
+
+var Media Storage
+
+// Setup picks the media backend. Only the local filesystem is supported;
+// MEDIA_ROOT and MEDIA_URL default to ./uploads served under /media.
+func Setup() {
+	root := os.Getenv("MEDIA_ROOT")
+	if root == "" {
+		root = "./uploads"
+	}
+
+	baseURL := os.Getenv("MEDIA_URL")
+	if baseURL == "" {
+		baseURL = "/media"
+	}
+
+	Media = NewLocal(root, baseURL)
+}
@@ -1,7 +1,9 @@
 package util
 
 import (
+	"strings"
 	"time"
+	"unicode"
 
 	"github.com/dgrijalva/jwt-go"
 )
@@ -28,3 +30,26 @@ func Parsejwt(cookie string) (string, error) {
 	claims := token.Claims.(*jwt.StandardClaims)
 	return claims.Issuer, nil
 }
+
+// Slugify lowercases a name and joins its words with dashes, so "Sci Fi",
+// "sci-fi" and "SCI_FI" all become "sci-fi"
+func Slugify(name string) string {
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	return strings.Join(words, "-")
+}
+
+// SplitGenres splits a free-text genre such as "Action/Comedy" or
+// "action, comedy" into the trimmed genre names
+func SplitGenres(value string) []string {
+	var names []string
+	for _, name := range strings.FieldsFunc(value, func(r rune) bool {
+		return r == '/' || r == ',' || r == '|' || r == ';'
+	}) {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
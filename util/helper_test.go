@@ -0,0 +1,46 @@
+package util
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Sci Fi", "sci-fi"},
+		{"sci-fi", "sci-fi"},
+		{"SCI_FI", "sci-fi"},
+		{"  Film   Noir! ", "film-noir"},
+		{"درام", "درام"},
+		{"---", ""},
+	}
+
+	for _, tt := range tests {
+		if got := Slugify(tt.name); got != tt.want {
+			t.Errorf("Slugify(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSplitGenres(t *testing.T) {
+	tests := []struct {
+		value string
+		want  []string
+	}{
+		{"", nil},
+		{"Drama", []string{"Drama"}},
+		{"Action/Comedy", []string{"Action", "Comedy"}},
+		{"action, comedy ;  thriller|horror", []string{"action", "comedy", "thriller", "horror"}},
+		{" / , ", nil},
+		{"Science Fiction", []string{"Science Fiction"}},
+	}
+
+	for _, tt := range tests {
+		if got := SplitGenres(tt.value); !slices.Equal(got, tt.want) {
+			t.Errorf("SplitGenres(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}